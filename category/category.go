@@ -6,14 +6,18 @@ import (
 	"time"
 
 	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
 )
 
 // Name models a speed running category.
 // Example: Mario 64 16 star
 type Name struct {
-	ID   int64
-	Name string `validate:"required"`
-	Best *time.Duration
+	ID        int64
+	GameID    int64  // Zero for categories that aren't in a game.
+	Game      string // The name of the game. Only set when the category is loaded.
+	Name      string `validate:"required"`
+	Variables game.Variables
+	Best      *time.Duration
 	//	TotalRuns int64
 }
 
@@ -26,27 +30,93 @@ func (c *Name) Save(tx *sql.Tx) (sql.Result, error) {
 	if err := db.Validate(c); err != nil {
 		return nil, err
 	}
-	return db.Connection.Exec("INSERT INTO category(name) VALUES(?)", c.Name)
+	return tx.Exec("INSERT INTO category(name, game_id) VALUES(?, ?)", c.Name, nullID(c.GameID))
 }
 
-// All returns a slice of all saved category names.
-func All() ([]Name, error) {
+// Update sets the name and game of the category and replaces its variables.
+func (c *Name) Update(tx *sql.Tx) error {
+	if err := db.Validate(c); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE category SET name = ?, game_id = ? WHERE id = ?", c.Name, nullID(c.GameID), c.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", c, err)
+	}
+	if err := db.CheckUpdated(res, c.String()); err != nil {
+		return err
+	}
+	return SaveVariables(tx, c.ID, c.Variables)
+}
+
+// SaveVariables replaces the variables of the category with categoryID.
+func SaveVariables(tx *sql.Tx, categoryID int64, v game.Variables) error {
+	if _, err := tx.Exec("DELETE FROM category_variable WHERE category_id = ?", categoryID); err != nil {
+		return fmt.Errorf("failed to delete category variables: %w", err)
+	}
+	for name, value := range v {
+		if _, err := tx.Exec(
+			"INSERT INTO category_variable(category_id, name, value) VALUES (?, ?, ?)",
+			categoryID,
+			name,
+			value,
+		); err != nil {
+			return fmt.Errorf("failed to save category variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetVariables returns the variables of the category with categoryID.
+func GetVariables(q db.Querier, categoryID int64) (game.Variables, error) {
+	variables, err := queryVariables(q, "WHERE category_id = ?", categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return variables[categoryID], nil
+}
+
+// Returns the variables of the categories that match where, keyed by category ID.
+func queryVariables(q db.Querier, where string, args ...interface{}) (map[int64]game.Variables, error) {
+	rows, err := q.Query("SELECT category_id, name, value FROM category_variable "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category variables: %w", err)
+	}
+	return game.ScanVariables(rows)
+}
+
+// Returns nil for a zero ID so that it's stored as NULL.
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// All returns a slice of all saved category names that have a route.
+// They are grouped by game, ordered by the game's name, with categories that aren't in a game last.
+func All(q db.Querier) ([]Name, error) {
 	var (
-		result []Name
-		best   *int64
+		result   []Name
+		best     *int64
+		gameID   *int64
+		gameName *string
 	)
 
 	query := `
-        SELECT c.id, 
-               c.name, 
-               MIN(ifnull(run.milliseconds,0)) AS pb
+        SELECT c.id,
+               c.name,
+               c.game_id,
+               g.name,
+               MIN(best.nanoseconds) AS pb
         FROM category AS C
         JOIN route AS r ON r.category_id = c.id
-        LEFT JOIN run ON run.route_id = r.id
+        LEFT JOIN game AS g ON g.id = c.game_id
+        LEFT JOIN route_best AS best ON best.route_id = r.id
         GROUP BY c.id
-        ORDER BY c.id`
+        ORDER BY g.name IS NULL, g.name, c.id`
 
-	rows, err := db.Connection.Query(query)
+	rows, err := q.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
@@ -57,16 +127,63 @@ func All() ([]Name, error) {
 		if err := rows.Scan(
 			&c.ID,
 			&c.Name,
+			&gameID,
+			&gameName,
 			&best,
 		); err != nil {
 			panic(err)
 		}
-		if best != nil {
-			dur := time.Duration(*best * 1e6)
-			c.Best = &dur
+		c.Best = db.ToNullDuration(best)
+		if gameID != nil {
+			c.GameID = *gameID
+			c.Game = *gameName
 		}
 		result = append(result, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	variables, err := queryVariables(q, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Variables = variables[result[i].ID]
+	}
 	return result, nil
 }
+
+// GetByName returns the category with name.
+// Returns nil when no category has the name.
+func GetByName(q db.Querier, name string) (*Name, error) {
+	var (
+		gameID   *int64
+		gameName *string
+	)
+
+	c := new(Name)
+	err := q.
+		QueryRow(`
+SELECT c.id, c.name, c.game_id, g.name
+FROM category AS c
+LEFT JOIN game AS g ON g.id = c.game_id
+WHERE c.name = ?`, name).
+		Scan(&c.ID, &c.Name, &gameID, &gameName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category %#v: %w", name, err)
+	}
+	if gameID != nil {
+		c.GameID = *gameID
+		c.Game = *gameName
+	}
+
+	c.Variables, err = GetVariables(q, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/game"
+)
+
+// Lists the categories by game, or changes the game and variables of the category that is named.
+func categoryCommand(args []string) error {
+	flags := flag.NewFlagSet("category", flag.ExitOnError)
+	gameName := flags.String("game", "", "move the category to the game, which is created when it doesn't exist")
+	noGame := flags.Bool("no-game", false, "take the category out of its game")
+	vars := flags.String("vars", "", "replace the category's variables, such as \"platform=N64, region=NTSC-U\"")
+	clearVars := flags.Bool("clear-vars", false, "remove the category's variables")
+	rename := flags.String("rename", "", "new name for the category")
+	flags.Parse(args)
+
+	name := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if name == "" {
+		return listCategories()
+	}
+	if *gameName != "" && *noGame {
+		return errors.New("category: pass either -game or -no-game")
+	}
+	if *vars != "" && *clearVars {
+		return errors.New("category: pass either -vars or -clear-vars")
+	}
+
+	c, err := storage.Categories().GetByName(name)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("category: no category is named %#v", name)
+	}
+
+	changed := false
+	if *gameName != "" {
+		if c.GameID, err = getOrSaveGame(*gameName); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if *noGame {
+		c.GameID = 0
+		changed = true
+	}
+	if *vars != "" {
+		if c.Variables, err = game.ParseVariables(*vars); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if *clearVars {
+		c.Variables = nil
+		changed = true
+	}
+	if *rename != "" {
+		c.Name = *rename
+		changed = true
+	}
+
+	if changed {
+		if err := storage.Categories().Update(c); err != nil {
+			return err
+		}
+		if c, err = storage.Categories().GetByName(c.Name); err != nil {
+			return err
+		}
+	}
+
+	printCategory(c)
+	return nil
+}
+
+// Prints every category that has a route under its game.
+func listCategories() error {
+	categories, err := storage.Categories().All()
+	if err != nil {
+		return err
+	}
+
+	for i, c := range categories {
+		if i == 0 || c.Game != categories[i-1].Game {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(gameHeader(c.Game))
+			fmt.Println(divider)
+		}
+		fmt.Printf("%s%s\n", c.Name, variablesSuffix(c.Variables))
+	}
+	return nil
+}
+
+func printCategory(c *category.Name) {
+	fmt.Printf("Category: %s\n", c.Name)
+	if c.Game != "" {
+		fmt.Printf("Game: %s\n", c.Game)
+	}
+	if len(c.Variables) > 0 {
+		fmt.Printf("Variables: %s\n", c.Variables)
+	}
+}
+
+// Returns the variables in parentheses, or nothing when there are none.
+func variablesSuffix(v game.Variables) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", v)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/game"
 	"github.com/knoebber/gsplits/route"
 )
 
@@ -20,6 +21,13 @@ func getCategoryName() string {
 	return scanner.Text()
 }
 
+func getGameName() string {
+	scanner := bufio.NewScanner(os.Stdin)
+	fmt.Printf("New game name, or nothing for no game: ")
+	scanner.Scan()
+	return strings.TrimSpace(scanner.Text())
+}
+
 func getRouteID(routes []route.Name) int64 {
 	fmt.Println("Choose a route")
 	for i, route := range routes {
@@ -28,7 +36,77 @@ func getRouteID(routes []route.Name) int64 {
 	return routes[promptListSelect(len(routes))].ID
 }
 
-func setupNewRoute(categoryID int64) (routeID int64, err error) {
+// Lists the matches under a header for each game, in the order that the games first match.
+func getMatchID(matches []route.Match) int64 {
+	var (
+		games  []string
+		byGame = map[string][]route.Match{}
+	)
+
+	for _, match := range matches {
+		if _, ok := byGame[match.GameName]; !ok {
+			games = append(games, match.GameName)
+		}
+		byGame[match.GameName] = append(byGame[match.GameName], match)
+	}
+
+	fmt.Println("Choose a route")
+	options := []route.Match{}
+	for _, g := range games {
+		if len(games) > 1 {
+			fmt.Println(gameHeader(g))
+		}
+		for _, match := range byGame[g] {
+			options = append(options, match)
+			fmt.Printf("(%d) %s\n", len(options), match)
+		}
+	}
+	return options[promptListSelect(len(options))].ID
+}
+
+func gameHeader(name string) string {
+	if name == "" {
+		return "No game:"
+	}
+	return name + ":"
+}
+
+// Asks for an existing game or the name of a new one.
+// A new game isn't saved here, so that aborting the wizard doesn't leave a game without categories.
+// Returns the zero game when the runner chooses not to use a game, or when there aren't any games.
+func chooseGame() (g game.Name, err error) {
+	games, err := storage.Games().All()
+	if err != nil || len(games) == 0 {
+		return g, err
+	}
+
+	if promptYN("Use existing game?") {
+		fmt.Println("Choose a game")
+		for i, g := range games {
+			fmt.Printf("(%d) %s\n", i+1, g.Name)
+		}
+		fmt.Printf("(%d) No game\n", len(games)+1)
+
+		i := promptListSelect(len(games) + 1)
+		if i == len(games) {
+			return g, nil
+		}
+		return games[i], nil
+	}
+
+	g.Name = getGameName()
+	if g.Name == "" {
+		return g, nil
+	}
+	existing, err := storage.Games().GetByName(g.Name)
+	if err != nil || existing == nil {
+		return g, err
+	}
+	return *existing, nil
+}
+
+// Asks for the name and splits of a new route and saves them with save.
+func setupNewRoute(save func(name string, splitNames []string) (int64, error)) (routeID int64, err error) {
 	var (
 		newRouteName string
 	)
@@ -59,64 +137,103 @@ func setupNewRoute(categoryID int64) (routeID int64, err error) {
 	}
 
 	exitWhenNo("Save?")
-	return saveRoute(categoryID, newRouteName, splitNames)
+	return save(newRouteName, splitNames)
 }
 
 func findRoute(name string) (routeID int64, err error) {
-	routes, err := route.SearchNames(name)
+	var routes, matches []route.Match
+
+	routes, err = storage.Routes().All()
 	if err != nil {
 		return 0, err
 	}
-	if len(routes) == 1 {
-		return routes[0].ID, nil
+
+	matches = route.Search(routes, name)
+	if len(matches) == 1 {
+		return matches[0].ID, nil
+	}
+	if len(matches) > 1 {
+		return getMatchID(matches), nil
+	}
+
+	matches = route.Suggest(routes, name)
+	fmt.Printf("No routes match %#v\n", name)
+	if len(matches) == 0 {
+		return wizard()
+	}
+
+	fmt.Println("Did you mean")
+	for i, match := range matches {
+		fmt.Printf("(%d) %s\n", i+1, match)
 	}
-	if len(routes) == 0 {
-		fmt.Printf("No route names contain %v\n", name)
+	fmt.Printf("(%d) None of these\n", len(matches)+1)
+
+	i := promptListSelect(len(matches) + 1)
+	if i == len(matches) {
 		return wizard()
 	}
-	return getRouteID(routes), nil
+	return matches[i].ID, nil
 }
 
 // Walks the user through setting up or getting a route.
+// The game is chosen first so that only its categories are listed.
+// A new game and category are saved with the new route, once the runner confirms it.
 func wizard() (routeID int64, err error) {
 	var (
+		all        []category.Name
 		categories []category.Name
 		routes     []route.Name
+		g          game.Name
 		categoryID int64
 	)
 
-	categories, err = category.All()
+	g, err = chooseGame()
 	if err != nil {
 		return
 	}
 
-	if len(categories) == 0 || !promptYN("Use existing category?") {
-		categoryID, err = saveCategory(getCategoryName())
+	// A game that isn't saved yet doesn't have categories.
+	if g.ID != 0 || g.Name == "" {
+		all, err = storage.Categories().All()
 		if err != nil {
 			return
 		}
-	} else {
-		fmt.Println("Choose a category")
-		for i, category := range categories {
-			fmt.Printf("(%d) %s\n", i+1, category.Name)
+		for _, c := range all {
+			if c.GameID == g.ID {
+				categories = append(categories, c)
+			}
 		}
-		categoryID = categories[promptListSelect(len(categories))].ID
 	}
 
-	routes, err = route.GetByCategory(categoryID)
+	if len(categories) == 0 || !promptYN("Use existing category?") {
+		c := &category.Name{Name: getCategoryName(), GameID: g.ID}
+		return setupNewRoute(func(name string, splitNames []string) (int64, error) {
+			if c.GameID == 0 && g.Name != "" {
+				if c.GameID, err = getOrSaveGame(g.Name); err != nil {
+					return 0, err
+				}
+			}
+			return saveRouteWithCategory(c, name, splitNames)
+		})
+	}
+
+	fmt.Println("Choose a category")
+	for i, category := range categories {
+		fmt.Printf("(%d) %s\n", i+1, category.Name)
+	}
+	categoryID = categories[promptListSelect(len(categories))].ID
+
+	routes, err = storage.Routes().GetByCategory(categoryID)
 	if err != nil {
 		return
 	}
 
-	if len(routes) == 0 || !promptYN("Use existing route?") {
-		routeID, err = setupNewRoute(categoryID)
-		if err != nil {
-			return
-		}
-	} else {
-		routeID = getRouteID(routes)
+	if len(routes) > 0 && promptYN("Use existing route?") {
+		return getRouteID(routes), nil
 	}
-	return
+	return setupNewRoute(func(name string, splitNames []string) (int64, error) {
+		return saveRoute(categoryID, name, splitNames)
+	})
 }
 
 // Presents a prompt to the user to pick an option number.
@@ -135,7 +252,6 @@ func promptListSelect(max int) int {
 			return i - 1
 		}
 	}
-	return 0
 }
 
 func promptYN(prompt string) bool {
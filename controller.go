@@ -1,134 +1,61 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
-	"time"
-
 	"github.com/knoebber/gsplits/category"
-	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/journal"
 	"github.com/knoebber/gsplits/route"
 	"github.com/knoebber/gsplits/split"
 )
 
-type saver interface {
-	String() string
-	Save(tx *sql.Tx) (sql.Result, error)
-}
-
-func save(s saver, tx *sql.Tx) (id int64, err error) {
-	var res sql.Result
-
-	res, err = s.Save(tx)
+// Returns the ID of the game with name, saving it when it doesn't exist.
+func getOrSaveGame(name string) (gameID int64, err error) {
+	g, err := storage.Games().GetByName(name)
 	if err != nil {
-		return 0, saveError(s, tx, err)
+		return 0, err
 	}
-
-	id, err = res.LastInsertId()
-	if err != nil {
-		return 0, idError(s, tx, err)
+	if g != nil {
+		return g.ID, nil
 	}
-	return
+	return storage.Games().Save(&game.Name{Name: name})
 }
 
-func idError(s saver, tx *sql.Tx, err error) error {
-	return fmt.Errorf("failed to get ID from %s: %w", s, db.Rollback(tx, err))
-}
-
-func saveError(s saver, tx *sql.Tx, err error) error {
-	return fmt.Errorf("failed to save %s: %w", s, db.Rollback(tx, err))
-}
-
-func saveCategory(name string) (categoryID int64, err error) {
-	var tx *sql.Tx
-
-	tx, err = db.Connection.Begin()
-	if err != nil {
-		fmt.Errorf("failed to start save category transaction: %w", err)
-	}
-
-	categoryName := &category.Name{
-		Name: name,
-	}
-	if categoryID, err = save(categoryName, tx); err != nil {
-		return
-	}
-
-	err = tx.Commit()
-	return
+// Saves a route in a new category.
+// The category isn't saved when the route fails to save.
+func saveRouteWithCategory(c *category.Name, name string, splitNames []string) (routeID int64, err error) {
+	return storage.Routes().SaveWithCategory(c, &route.Name{Name: name}, splitNames)
 }
 
 func saveRoute(categoryID int64, name string, splitNames []string) (routeID int64, err error) {
-	var tx *sql.Tx
-
-	tx, err = db.Connection.Begin()
-	if err != nil {
-		fmt.Errorf("failed to start save route transaction: %w", err)
-	}
-
 	routeName := &route.Name{
 		Name:       name,
 		CategoryID: categoryID,
 	}
-	routeID, err = save(routeName, tx)
-	if err != nil {
-		return
-	}
-
-	sn := &split.Name{
-		RouteID: routeID,
-	}
-	for i, splitName := range splitNames {
-		sn.Position = i + 1
-		sn.Name = splitName
-
-		_, err = save(sn, tx)
-		if err != nil {
-			return
-		}
-	}
-
-	err = tx.Commit()
-	return
+	return storage.Routes().Save(routeName, splitNames)
 }
 
-func saveRun(routeID int64, segments []time.Duration, totalDuration time.Duration) (runID int64, err error) {
-	var (
-		tx         *sql.Tx
-		splitNames []split.Name
-	)
-
-	splitNames, err = split.GetByRoute(routeID)
-	if err != nil {
-		return
-	}
-
-	tx, err = db.Connection.Begin()
-	if err != nil {
-		fmt.Errorf("failed to start save run transaction: %w", err)
-	}
-
+// Saves the completed segments of a run along with when they were split.
+// A run from a later split is saved as a partial run with only the segments that were run,
+// and a run that isn't done is saved as unfinished so that it is never the best run.
+func saveRun(j *journal.Run, comment string, tags []string, variables game.Variables) (runID int64, err error) {
 	run := &route.Run{
-		Duration: totalDuration,
-		RouteID:  routeID,
-	}
-
-	runID, err = save(run, tx)
-	if err != nil {
-		return
-	}
-
-	for i, segment := range segments {
-		d := &split.Duration{
-			RunID:    runID,
-			Duration: segment,
-			NameID:   splitNames[i].ID,
-		}
-		if _, err = save(d, tx); err != nil {
-			return
+		Duration:   j.Total(),
+		RouteID:    j.RouteID,
+		StartIndex: j.StartIndex,
+		Unfinished: !j.IsDone(),
+		StartedAt:  j.Start,
+		Events:     j.Events,
+		Comment:    comment,
+		Tags:       tags,
+		Variables:  variables,
+	}
+
+	segments := make([]split.Duration, j.Completed())
+	for i := range segments {
+		segments[i].Duration = j.Segments[j.StartIndex+i]
+		if j.StartIndex+i < len(j.SplitTimes) {
+			segments[i].EndedAt = j.SplitTimes[j.StartIndex+i]
 		}
 	}
-
-	err = tx.Commit()
-	return
+	return storage.Runs().Save(run, segments)
 }
@@ -3,7 +3,10 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
+	"time"
+
 	// Driver for sql
 	_ "github.com/mattn/go-sqlite3"
 	"gopkg.in/go-playground/validator.v9"
@@ -12,47 +15,120 @@ import (
 // Connection is a sql connection.
 var Connection *sql.DB
 
-var validate *validator.Validate
+var validate = validator.New()
+
+// Querier runs queries against a database connection or transaction.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
 // The name of the sqlite3 db file.
 // Created as a hidden file in the home directory: ~/.gsplits
 const dbName = "gsplits"
 
-// Creates the required tables if they doesn't exist
-func createTables() error {
+// The latest schema.
+// Changes to it need a migration so that existing databases are upgraded.
+var tables = []string{
 	// TODO refactor tables: split => segment
-	tables := []string{
-		`CREATE TABLE IF NOT EXISTS category(
-                        id   INTEGER PRIMARY KEY,
-	                name TEXT NOT NULL UNIQUE
-                 );`,
-		`CREATE TABLE IF NOT EXISTS route(
-                        id          INTEGER PRIMARY KEY,
-                        name        TEXT NOT NULL UNIQUE,
-                        category_id INTEGER
-                 );`,
-		`CREATE TABLE IF NOT EXISTS run(
-                        id           INTEGER PRIMARY KEY,
-                        route_id     INTEGER,
-                        milliseconds INTEGER,
-	                created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
-                 );`,
-		`CREATE TABLE IF NOT EXISTS split_name(
-                        id       INTEGER PRIMARY KEY,
-		        route_id INTEGER,
-		        position INTEGER,
-                        name     TEXT
-                 );`,
-		`CREATE TABLE IF NOT EXISTS split(
-                        id            INTEGER PRIMARY KEY,
-                        run_id        INTEGER,
-                        split_name_id INTEGER,
-                        milliseconds  INTEGER
-                 );`,
-	}
+	`CREATE TABLE game(
+                id   INTEGER PRIMARY KEY,
+                name TEXT NOT NULL UNIQUE
+         );`,
+	`CREATE TABLE category(
+                id      INTEGER PRIMARY KEY,
+                name    TEXT NOT NULL UNIQUE,
+                game_id INTEGER REFERENCES game(id) ON DELETE SET NULL
+         );`,
+	`CREATE TABLE category_variable(
+                category_id INTEGER NOT NULL REFERENCES category(id) ON DELETE CASCADE,
+                name        TEXT NOT NULL,
+                value       TEXT NOT NULL,
+                PRIMARY KEY(category_id, name)
+         );`,
+	`CREATE TABLE route(
+                id          INTEGER PRIMARY KEY,
+                name        TEXT NOT NULL UNIQUE,
+                category_id INTEGER NOT NULL REFERENCES category(id) ON DELETE CASCADE
+         );`,
+	`CREATE TABLE run(
+                id          INTEGER PRIMARY KEY,
+                route_id    INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                nanoseconds INTEGER,
+                created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+                started_at  DATETIME,
+                start_index INTEGER NOT NULL DEFAULT 0,
+                invalid     BOOLEAN NOT NULL DEFAULT 0,
+                comment     TEXT NOT NULL DEFAULT '',
+                unfinished  BOOLEAN NOT NULL DEFAULT 0
+         );`,
+	`CREATE TABLE run_tag(
+                run_id INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                tag    TEXT NOT NULL,
+                PRIMARY KEY(run_id, tag)
+         );`,
+	`CREATE TABLE run_variable(
+                run_id INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                name   TEXT NOT NULL,
+                value  TEXT NOT NULL,
+                PRIMARY KEY(run_id, name)
+         );`,
+	`CREATE TABLE split_name(
+                id       INTEGER PRIMARY KEY,
+                route_id INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                position INTEGER,
+                name     TEXT,
+                notes    TEXT NOT NULL DEFAULT ''
+         );`,
+	`CREATE TABLE split(
+                id            INTEGER PRIMARY KEY,
+                run_id        INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                split_name_id INTEGER NOT NULL REFERENCES split_name(id) ON DELETE CASCADE,
+                nanoseconds   INTEGER,
+                ended_at      DATETIME,
+                invalid       BOOLEAN NOT NULL DEFAULT 0
+         );`,
+	`CREATE TABLE gold(
+                split_name_id INTEGER PRIMARY KEY REFERENCES split_name(id) ON DELETE CASCADE,
+                nanoseconds   INTEGER NOT NULL
+         );`,
+	`CREATE TABLE route_best(
+                route_id    INTEGER PRIMARY KEY REFERENCES route(id) ON DELETE CASCADE,
+                run_id      INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                nanoseconds INTEGER NOT NULL
+         );`,
+	`CREATE TABLE event(
+                id          INTEGER PRIMARY KEY,
+                route_id    INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                run_id      INTEGER REFERENCES run(id) ON DELETE CASCADE,
+                type        TEXT NOT NULL,
+                split_index INTEGER NOT NULL,
+                happened_at DATETIME NOT NULL
+         );`,
+	`CREATE TABLE practice(
+                id            INTEGER PRIMARY KEY,
+                split_name_id INTEGER NOT NULL REFERENCES split_name(id) ON DELETE CASCADE,
+                nanoseconds   INTEGER NOT NULL,
+                ended_at      DATETIME,
+                invalid       BOOLEAN NOT NULL DEFAULT 0
+         );`,
+	`CREATE INDEX category_game_id ON category(game_id);`,
+	`CREATE INDEX route_category_id ON route(category_id);`,
+	`CREATE INDEX run_route_id ON run(route_id);`,
+	`CREATE INDEX run_tag_tag ON run_tag(tag);`,
+	`CREATE INDEX split_name_route_id ON split_name(route_id, position);`,
+	`CREATE INDEX split_run_id ON split(run_id, split_name_id);`,
+	`CREATE INDEX split_split_name_id ON split(split_name_id);`,
+	`CREATE INDEX event_route_id ON event(route_id, happened_at);`,
+	`CREATE INDEX event_run_id ON event(run_id);`,
+	`CREATE INDEX practice_split_name_id ON practice(split_name_id);`,
+}
 
+// Creates the tables in a new database.
+func createTables(tx *sql.Tx) error {
 	for _, table := range tables {
-		_, err := Connection.Exec(table)
+		_, err := tx.Exec(table)
 		if err != nil {
 			return fmt.Errorf("failed to create tables: %w", err)
 		}
@@ -60,7 +136,7 @@ func createTables() error {
 	return nil
 }
 
-// Start opens a connection a sqlite3 database.
+// Start opens a connection to the sqlite3 database in the home directory.
 // It will create a new database if the db file does not exist.
 func Start() error {
 	var (
@@ -73,13 +149,26 @@ func Start() error {
 		return err
 	}
 
-	Connection, err = sql.Open("sqlite3", fmt.Sprintf("%s/.%s.db", home, dbName))
+	Connection, err = Open(fmt.Sprintf("%s/.%s.db", home, dbName))
+	return err
+}
+
+// Open opens a connection to the sqlite3 database at path.
+// It will create a new database if the db file does not exist,
+// and migrates databases that were created by older versions.
+// Foreign keys are enforced on every connection.
+// The path is escaped so that names with '?' or '#' aren't read as URI parameters.
+func Open(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1", url.PathEscape(path)))
 	if err != nil {
-		return fmt.Errorf("failed to open sqlite datebase: %w", err)
+		return nil, fmt.Errorf("failed to open sqlite datebase: %w", err)
 	}
 
-	validate = validator.New()
-	return createTables()
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
 }
 
 // Close closes the connection.
@@ -93,6 +182,33 @@ func Validate(s interface{}) error {
 	return validate.Struct(s)
 }
 
+// ValidateExcept validates a struct without the fields that are set when it is inserted, such as a parent's ID.
+func ValidateExcept(s interface{}, fields ...string) error {
+	return validate.StructExcept(s, fields...)
+}
+
+// NullTime returns nil for the zero time so that it's stored as NULL.
+// Other times are stored in UTC, like CURRENT_TIMESTAMP.
+func NullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// CheckUpdated returns an error when an update or delete didn't change any rows.
+// name describes what was being changed.
+func CheckUpdated(res sql.Result, name string) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%s not found", name)
+	}
+	return nil
+}
+
 // Rollback rolls back a database transaction.
 // It always returns an error.
 func Rollback(tx *sql.Tx, err error) error {
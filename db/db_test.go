@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenEscapesPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsplits-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runs?mode=ro#1 %20.db")
+
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec("INSERT INTO category(name) VALUES ('Any%')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("database wasn't created at %s: %v", path, err)
+	}
+
+	var foreignKeys bool
+	if err := conn.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if !foreignKeys {
+		t.Error("foreign keys aren't enforced")
+	}
+}
+
+func TestMigrateRemovesOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsplits-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "old.db")
+
+	// The tables as they were before the first migration.
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := []string{
+		`CREATE TABLE category(id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)`,
+		`CREATE TABLE route(id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, category_id INTEGER)`,
+		`CREATE TABLE run(
+                        id           INTEGER PRIMARY KEY,
+                        route_id     INTEGER,
+                        milliseconds INTEGER,
+                        created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+                 )`,
+		`CREATE TABLE split_name(id INTEGER PRIMARY KEY, route_id INTEGER, position INTEGER, name TEXT)`,
+		`CREATE TABLE split(id INTEGER PRIMARY KEY, run_id INTEGER, split_name_id INTEGER, milliseconds INTEGER)`,
+		`INSERT INTO category VALUES (1, 'Any%')`,
+		`INSERT INTO route VALUES (1, 'Glitchless', 1), (2, 'Lost', 9)`,
+		`INSERT INTO run(id, route_id, milliseconds) VALUES (1, 1, 3000), (2, 2, 2000), (3, 9, 1000)`,
+		`INSERT INTO split_name VALUES (1, 1, 0, 'A'), (2, 2, 0, 'B')`,
+		`INSERT INTO split VALUES (1, 1, 1, 3000), (2, 2, 2, 2000), (3, 1, 9, 1000)`,
+	}
+	for _, statement := range statements {
+		if _, err := old.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old.Close()
+
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var version int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("version = %d, want %d", version, len(migrations))
+	}
+
+	for table, want := range map[string]int{"route": 1, "run": 1, "split_name": 1, "split": 1} {
+		var count int
+		if err := conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Errorf("%s has %d rows, want %d", table, count, want)
+		}
+	}
+
+	var nanoseconds int64
+	if err := conn.QueryRow("SELECT nanoseconds FROM route_best WHERE route_id = 1").Scan(&nanoseconds); err != nil {
+		t.Fatal(err)
+	}
+	if nanoseconds != 3000*1000000 {
+		t.Errorf("route best = %d, want %d", nanoseconds, 3000*1000000)
+	}
+}
+
+func TestMigrateStoresUTC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsplits-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "local.db")
+
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Times that were saved in local time before storeUTC.
+	statements := []string{
+		`INSERT INTO category(id, name) VALUES (1, 'Any%')`,
+		`INSERT INTO route(id, name, category_id) VALUES (1, 'Glitchless', 1)`,
+		`INSERT INTO run(id, route_id, nanoseconds, started_at) VALUES (1, 1, 1000, '2020-05-01 23:30:00.5-07:00')`,
+		`INSERT INTO event(route_id, run_id, type, split_index, happened_at)
+                 VALUES (1, 1, 'start', 0, '2020-05-01 23:30:00.5-07:00')`,
+	}
+	for _, statement := range statements {
+		if _, err := conn.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var version int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if err := runMigration(conn, version, storeUTC); err != nil {
+		t.Fatal(err)
+	}
+
+	var startedAt, happenedAt string
+	if err := conn.QueryRow(`
+SELECT CAST(run.started_at AS TEXT), CAST(event.happened_at AS TEXT)
+FROM run JOIN event ON event.run_id = run.id`).Scan(&startedAt, &happenedAt); err != nil {
+		t.Fatal(err)
+	}
+	want := "2020-05-02 06:30:00.5+00:00"
+	if startedAt != want || happenedAt != want {
+		t.Errorf("times = %s and %s, want %s", startedAt, happenedAt, want)
+	}
+}
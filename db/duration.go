@@ -0,0 +1,26 @@
+package db
+
+import "time"
+
+// Durations are stored as integer nanoseconds.
+// Reads and writes convert with these helpers so that the unit is only known here.
+
+// FromDuration returns the value that d is stored as.
+func FromDuration(d time.Duration) int64 {
+	return d.Nanoseconds()
+}
+
+// ToDuration returns the duration of a stored value.
+func ToDuration(v int64) time.Duration {
+	return time.Duration(v)
+}
+
+// ToNullDuration returns the duration of a nullable stored value.
+// Returns nil when v is nil.
+func ToNullDuration(v *int64) *time.Duration {
+	if v == nil {
+		return nil
+	}
+	d := ToDuration(*v)
+	return &d
+}
@@ -0,0 +1,457 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Each migration upgrades a database from the version at its index to the next version.
+// The version is stored in the database's user_version.
+// New databases are created with the latest tables and start at len(migrations).
+var migrations = []func(tx *sql.Tx) error{
+	addForeignKeys,
+	addBestTables,
+	storeNanoseconds,
+	addTimestamps,
+	addPractice,
+	addStartIndex,
+	addInvalid,
+	addNotes,
+	addRunTags,
+	addGames,
+	storeUTC,
+	addPracticeInvalid,
+	addRunUnfinished,
+}
+
+// Creates the tables in a new database or runs the migrations that an existing database is missing.
+func migrate(conn *sql.DB) error {
+	var (
+		version    int
+		tableCount int
+	)
+
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to get database version: %w", err)
+	}
+	if err := conn.
+		QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'category'").
+		Scan(&tableCount); err != nil {
+		return fmt.Errorf("failed to check for tables: %w", err)
+	}
+
+	if tableCount == 0 {
+		return runMigration(conn, len(migrations), createTables)
+	}
+
+	for ; version < len(migrations); version++ {
+		if err := runMigration(conn, version+1, migrations[version]); err != nil {
+			return fmt.Errorf("failed to migrate database to version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// Runs f in a transaction and sets the database version.
+// Foreign keys are turned off while f runs so that tables can be rebuilt,
+// then checked before the transaction is committed.
+func runMigration(conn *sql.DB, version int, f func(tx *sql.Tx) error) (err error) {
+	ctx := context.Background()
+
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if _, err = c.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, fkErr := c.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err == nil {
+			err = fkErr
+		}
+	}()
+
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = f(tx); err != nil {
+		return Rollback(tx, err)
+	}
+	if err = checkForeignKeys(tx); err != nil {
+		return Rollback(tx, err)
+	}
+	if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func checkForeignKeys(tx *sql.Tx) error {
+	var (
+		table  string
+		rowID  *int64
+		parent string
+		fkID   int64
+	)
+
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s row %v references a missing %s", table, rowID, parent)
+	}
+	return rows.Err()
+}
+
+// Rows that reference missing rows can't be shown.
+// addForeignKeys removes them before rebuilding the tables.
+var orphans = []struct {
+	description string
+	statement   string
+}{
+	{
+		"routes without a category",
+		`DELETE FROM route WHERE category_id IS NULL OR category_id NOT IN (SELECT id FROM category)`,
+	},
+	{
+		"runs without a route",
+		`DELETE FROM run WHERE route_id IS NULL OR route_id NOT IN (SELECT id FROM route)`,
+	},
+	{
+		"split names without a route",
+		`DELETE FROM split_name WHERE route_id IS NULL OR route_id NOT IN (SELECT id FROM route)`,
+	},
+	{
+		"splits without a run or split name",
+		`DELETE FROM split
+                 WHERE run_id IS NULL
+                 OR split_name_id IS NULL
+                 OR run_id NOT IN (SELECT id FROM run)
+                 OR split_name_id NOT IN (SELECT id FROM split_name)`,
+	},
+}
+
+// Rebuilds the tables with foreign keys and indexes.
+// Orphaned rows are removed first, and the number of removed rows is printed.
+func addForeignKeys(tx *sql.Tx) error {
+	for _, orphan := range orphans {
+		res, err := tx.Exec(orphan.statement)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			fmt.Printf("Removed %d %s while upgrading the database\n", count, orphan.description)
+		}
+	}
+
+	statements := []string{
+		`CREATE TABLE new_route(
+                        id          INTEGER PRIMARY KEY,
+                        name        TEXT NOT NULL UNIQUE,
+                        category_id INTEGER NOT NULL REFERENCES category(id) ON DELETE CASCADE
+                 );`,
+		`CREATE TABLE new_run(
+                        id           INTEGER PRIMARY KEY,
+                        route_id     INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                        milliseconds INTEGER,
+                        created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+                 );`,
+		`CREATE TABLE new_split_name(
+                        id       INTEGER PRIMARY KEY,
+                        route_id INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                        position INTEGER,
+                        name     TEXT
+                 );`,
+		`CREATE TABLE new_split(
+                        id            INTEGER PRIMARY KEY,
+                        run_id        INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                        split_name_id INTEGER NOT NULL REFERENCES split_name(id) ON DELETE CASCADE,
+                        milliseconds  INTEGER
+                 );`,
+
+		`INSERT INTO new_route SELECT id, name, category_id FROM route`,
+		`INSERT INTO new_run SELECT id, route_id, milliseconds, created_at FROM run`,
+		`INSERT INTO new_split_name SELECT id, route_id, position, name FROM split_name`,
+		`INSERT INTO new_split SELECT id, run_id, split_name_id, milliseconds FROM split`,
+
+		`DROP TABLE split`,
+		`DROP TABLE split_name`,
+		`DROP TABLE run`,
+		`DROP TABLE route`,
+
+		`ALTER TABLE new_route RENAME TO route`,
+		`ALTER TABLE new_run RENAME TO run`,
+		`ALTER TABLE new_split_name RENAME TO split_name`,
+		`ALTER TABLE new_split RENAME TO split`,
+
+		`CREATE INDEX route_category_id ON route(category_id);`,
+		`CREATE INDEX run_route_id ON run(route_id);`,
+		`CREATE INDEX split_name_route_id ON split_name(route_id, position);`,
+		`CREATE INDEX split_run_id ON split(run_id);`,
+		`CREATE INDEX split_split_name_id ON split(split_name_id);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds the tables that keep the golds and the best run of each route.
+// They're filled from the existing runs, then kept up to date as runs are saved.
+func addBestTables(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE gold(
+                        split_name_id INTEGER PRIMARY KEY REFERENCES split_name(id) ON DELETE CASCADE,
+                        milliseconds  INTEGER NOT NULL
+                 );`,
+		`CREATE TABLE route_best(
+                        route_id     INTEGER PRIMARY KEY REFERENCES route(id) ON DELETE CASCADE,
+                        run_id       INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                        milliseconds INTEGER NOT NULL
+                 );`,
+		`INSERT INTO gold
+                 SELECT split_name_id, MIN(milliseconds)
+                 FROM split
+                 WHERE milliseconds IS NOT NULL
+                 GROUP BY split_name_id`,
+		`INSERT INTO route_best
+                 SELECT route_id, id, milliseconds
+                 FROM run
+                 WHERE id = (
+                   SELECT best.id
+                   FROM run AS best
+                   WHERE best.route_id = run.route_id AND best.milliseconds IS NOT NULL
+                   ORDER BY best.milliseconds, best.id
+                   LIMIT 1
+                 )`,
+
+		// Finding the best run's segments needs both columns.
+		`DROP INDEX split_run_id`,
+		`CREATE INDEX split_run_id ON split(run_id, split_name_id);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stores durations in nanoseconds instead of milliseconds.
+func storeNanoseconds(tx *sql.Tx) error {
+	for _, table := range []string{"run", "split", "gold", "route_best"} {
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s RENAME COLUMN milliseconds TO nanoseconds", table),
+			fmt.Sprintf("UPDATE %s SET nanoseconds = nanoseconds * 1000000", table),
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Adds wall clock times for the start of runs, the end of splits and timer events.
+// Existing runs don't have them.
+func addTimestamps(tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE run ADD COLUMN started_at DATETIME`,
+		`ALTER TABLE split ADD COLUMN ended_at DATETIME`,
+		`CREATE TABLE event(
+                        id          INTEGER PRIMARY KEY,
+                        route_id    INTEGER NOT NULL REFERENCES route(id) ON DELETE CASCADE,
+                        run_id      INTEGER REFERENCES run(id) ON DELETE CASCADE,
+                        type        TEXT NOT NULL,
+                        split_index INTEGER NOT NULL,
+                        happened_at DATETIME NOT NULL
+                 );`,
+		`CREATE INDEX event_route_id ON event(route_id, happened_at);`,
+		`CREATE INDEX event_run_id ON event(run_id);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds the table of practice segments, which are kept apart from runs.
+func addPractice(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE practice(
+                        id            INTEGER PRIMARY KEY,
+                        split_name_id INTEGER NOT NULL REFERENCES split_name(id) ON DELETE CASCADE,
+                        nanoseconds   INTEGER NOT NULL,
+                        ended_at      DATETIME
+                 );`,
+		`CREATE INDEX practice_split_name_id ON practice(split_name_id);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds the split that a partial run started from.
+// Existing runs are full runs.
+func addStartIndex(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE run ADD COLUMN start_index INTEGER NOT NULL DEFAULT 0")
+	return err
+}
+
+// Adds flags for excluding runs and segments from bests and statistics.
+func addInvalid(tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE run ADD COLUMN invalid BOOLEAN NOT NULL DEFAULT 0`,
+		`ALTER TABLE split ADD COLUMN invalid BOOLEAN NOT NULL DEFAULT 0`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addNotes(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE split_name ADD COLUMN notes TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+func addRunTags(tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE run ADD COLUMN comment TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE run_tag(
+                        run_id INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                        tag    TEXT NOT NULL,
+                        PRIMARY KEY(run_id, tag)
+                 );`,
+		`CREATE INDEX run_tag_tag ON run_tag(tag);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addGames(tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE game(
+                        id   INTEGER PRIMARY KEY,
+                        name TEXT NOT NULL UNIQUE
+                 );`,
+		`ALTER TABLE category ADD COLUMN game_id INTEGER REFERENCES game(id) ON DELETE SET NULL`,
+		`CREATE TABLE category_variable(
+                        category_id INTEGER NOT NULL REFERENCES category(id) ON DELETE CASCADE,
+                        name        TEXT NOT NULL,
+                        value       TEXT NOT NULL,
+                        PRIMARY KEY(category_id, name)
+                 );`,
+		`CREATE TABLE run_variable(
+                        run_id INTEGER NOT NULL REFERENCES run(id) ON DELETE CASCADE,
+                        name   TEXT NOT NULL,
+                        value  TEXT NOT NULL,
+                        PRIMARY KEY(run_id, name)
+                 );`,
+		`CREATE INDEX category_game_id ON category(game_id);`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Converts the wall clock times that were saved in local time to UTC,
+// so that they sort and compare the same as created_at.
+func storeUTC(tx *sql.Tx) error {
+	columns := []struct{ table, column string }{
+		{"run", "started_at"},
+		{"split", "ended_at"},
+		{"event", "happened_at"},
+		{"practice", "ended_at"},
+	}
+
+	for _, c := range columns {
+		if err := columnToUTC(tx, c.table, c.column); err != nil {
+			return fmt.Errorf("failed to convert %s.%s to UTC: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func columnToUTC(tx *sql.Tx, table, column string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s WHERE %s IS NOT NULL", column, table, column))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	times := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			id int64
+			t  time.Time
+		)
+		if err := rows.Scan(&id, &t); err != nil {
+			return err
+		}
+		times[id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for id, t := range times {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column), t.UTC(), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds a flag for excluding practice segments from golds and statistics, like segments of runs.
+func addPracticeInvalid(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE practice ADD COLUMN invalid BOOLEAN NOT NULL DEFAULT 0")
+	return err
+}
+
+// Adds a flag for runs that were saved before their last split, so that they're never the best run.
+func addRunUnfinished(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE run ADD COLUMN unfinished BOOLEAN NOT NULL DEFAULT 0")
+	return err
+}
@@ -1,55 +1,41 @@
--- --- Use for debugging the getRoute function.
+-- --- Use for debugging the route.GetData function.
 -- --- $ sqlite3 ~/.gsplits.db
+-- --- sqlite> .parameter set :route_id 1
 -- --- sqlite> .read test_route_query.sql
 .mode column
 .headers on
 SELECT
-  sn.id AS split_name_id,
-  sn.name AS split_name,
-  MIN(golds.milliseconds) AS gold_split,
-  route_best.milliseconds AS route_best_split,
-  MIN(run.milliseconds) AS route_best,
   r.id AS route_id,
   r.name AS route_name,
-  COUNT(DISTINCT run.id) AS total_runs,
+  best.run_id AS best_run_id,
+  best.nanoseconds AS route_best,
+  (SELECT COUNT(*) FROM run WHERE run.route_id = r.id) AS total_runs,
   c.id AS category_id,
   c.name AS category_name,
-  category_best.milliseconds AS category_best
+  (
+    SELECT MIN(category_best.nanoseconds)
+    FROM route_best AS category_best
+    JOIN route ON route.id = category_best.route_id
+    WHERE route.category_id = c.id
+  ) AS category_best
 FROM
   route AS r
   JOIN category AS c ON c.id = r.category_id
-  JOIN split_name AS sn ON sn.route_id = r.id
-  LEFT JOIN split AS golds ON golds.split_name_id = sn.id
-  LEFT JOIN run ON run.route_id = r.id
-  LEFT JOIN (
-    SELECT
-      r.id AS route_id,
-      sn.id AS split_name_id,
-      s.milliseconds
-    FROM
-      category AS c
-      JOIN route AS r ON r.category_id = c.id
-      JOIN run ON run.route_id = r.id
-      JOIN split_name AS sn ON sn.route_id = r.id
-      JOIN split AS s ON s.split_name_id = sn.id
-      AND s.run_id = run.id
-    GROUP BY
-      r.id,
-      sn.id
-    HAVING
-      MIN(run.milliseconds) = run.milliseconds
-  ) AS route_best ON route_best.split_name_id = sn.id
-  LEFT JOIN (
-    SELECT
-      MIN(run.milliseconds) AS milliseconds,
-      c.id
-    FROM
-      category AS c
-      JOIN route AS r ON r.category_id = c.id
-      JOIN run ON run.route_id = r.id
-    GROUP BY
-      c.id
-  ) AS category_best ON category_best.id = c.id
-GROUP BY
-  sn.id
-ORDER BY r.id, sn.position;
+  LEFT JOIN route_best AS best ON best.route_id = r.id
+WHERE
+  r.id = :route_id;
+
+SELECT
+  sn.id AS split_name_id,
+  sn.name AS split_name,
+  gold.nanoseconds AS gold_split,
+  s.nanoseconds AS route_best_split
+FROM
+  split_name AS sn
+  LEFT JOIN gold ON gold.split_name_id = sn.id
+  LEFT JOIN route_best AS best ON best.route_id = sn.route_id
+  LEFT JOIN split AS s ON s.run_id = best.run_id AND s.split_name_id = sn.id
+WHERE
+  sn.route_id = :route_id
+ORDER BY
+  sn.position;
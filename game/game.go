@@ -0,0 +1,65 @@
+// Package game models the games that categories belong to
+// and the speedrun.com style variables of categories and runs.
+package game
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/knoebber/gsplits/db"
+)
+
+// Name is a game that has speedrun categories.
+// Example: Super Mario 64
+type Name struct {
+	ID   int64
+	Name string `validate:"required"`
+}
+
+func (g Name) String() string {
+	return fmt.Sprintf("game %#v", g.Name)
+}
+
+// Save inserts the game into the game table.
+func (g *Name) Save(tx *sql.Tx) (sql.Result, error) {
+	if err := db.Validate(g); err != nil {
+		return nil, err
+	}
+	return tx.Exec("INSERT INTO game(name) VALUES(?)", g.Name)
+}
+
+// All returns every game ordered by name.
+func All(q db.Querier) ([]Name, error) {
+	rows, err := q.Query("SELECT id, name FROM game ORDER BY name, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get games: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Name{}
+	for rows.Next() {
+		g := Name{}
+		if err := rows.Scan(&g.ID, &g.Name); err != nil {
+			return nil, err
+		}
+		result = append(result, g)
+	}
+	return result, rows.Err()
+}
+
+// GetByName returns the game with name.
+// Returns nil when no game has the name.
+func GetByName(q db.Querier, name string) (*Name, error) {
+	g := new(Name)
+
+	err := q.
+		QueryRow("SELECT id, name FROM game WHERE name = ?", name).
+		Scan(&g.ID, &g.Name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game %#v: %w", name, err)
+	}
+	return g, nil
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The variables that most games have.
+// Any other variable name can be used too.
+const (
+	Platform   = "platform"
+	Region     = "region"
+	Version    = "version"
+	Difficulty = "difficulty"
+)
+
+// Variables are properties of a category or run, such as platform=N64 or region=NTSC-J.
+// They are keyed by lower case name.
+type Variables map[string]string
+
+// ParseVariables parses variables from name=value pairs that are separated by commas.
+// Example: "platform=N64, region=NTSC-U"
+// Returns nil when s is blank.
+func ParseVariables(s string) (Variables, error) {
+	var result Variables
+
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("variable %q must look like name=value", strings.TrimSpace(pair))
+		}
+		name := strings.ToLower(strings.TrimSpace(pair[:i]))
+		value := strings.TrimSpace(pair[i+1:])
+		if name == "" || value == "" {
+			return nil, fmt.Errorf("variable %q needs a name and a value", strings.TrimSpace(pair))
+		}
+
+		if result == nil {
+			result = Variables{}
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// Names returns the names of the variables in order.
+func (v Variables) Names() []string {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String formats the variables so that they can be read by ParseVariables.
+func (v Variables) String() string {
+	pairs := make([]string, 0, len(v))
+	for _, name := range v.Names() {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, v[name]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Merge returns the variables of v and other.
+// Values from other replace the values in v, such as a run's platform replacing its category's.
+func (v Variables) Merge(other Variables) Variables {
+	if len(v) == 0 && len(other) == 0 {
+		return nil
+	}
+
+	result := Variables{}
+	for name, value := range v {
+		result[name] = value
+	}
+	for name, value := range other {
+		result[name] = value
+	}
+	return result
+}
+
+// ScanVariables reads rows of an owner ID, a variable name and a value.
+// The result is keyed by owner ID, such as a category or run ID.
+func ScanVariables(rows *sql.Rows) (map[int64]Variables, error) {
+	defer rows.Close()
+
+	result := map[int64]Variables{}
+	for rows.Next() {
+		var (
+			id          int64
+			name, value string
+		)
+		if err := rows.Scan(&id, &name, &value); err != nil {
+			return nil, err
+		}
+		if result[id] == nil {
+			result[id] = Variables{}
+		}
+		result[id][name] = value
+	}
+	return result, rows.Err()
+}
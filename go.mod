@@ -10,4 +10,5 @@ require (
 	github.com/rivo/tview v0.0.0-20191129065140-82b05c9fb329
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/validator.v9 v9.30.2
+	gopkg.in/yaml.v2 v2.2.7
 )
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/knoebber/gsplits/stats"
+)
+
+// Lists segments of a route that are suspiciously fast and asks whether to mark each one as invalid.
+// Practice segments are checked along with the segments of runs.
+func outliersCommand(args []string) error {
+	routeName := strings.TrimSpace(strings.Join(args, " "))
+	if routeName == "" {
+		return errors.New("outliers: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := storage.Routes().GetData(routeID)
+	if err != nil {
+		return err
+	}
+	durations, err := storage.Splits().GetDurations(routeID)
+	if err != nil {
+		return err
+	}
+	practice, err := storage.Practice().GetByRoute(routeID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", routeData.Category.Name, routeData.RouteName)
+	fmt.Printf(
+		"Segments more than %d standard deviations faster than their median\n",
+		stats.OutlierDeviations,
+	)
+	fmt.Println(divider)
+
+	found := 0
+	for i, sn := range routeData.SplitNames {
+		var (
+			times []time.Duration
+			ids   []int64
+			runs  []int64 // Zero for practice segments.
+		)
+		for _, d := range durations {
+			if d.NameID == sn.ID {
+				times = append(times, d.Duration)
+				ids = append(ids, d.ID)
+				runs = append(runs, d.RunID)
+			}
+		}
+		for _, p := range practice {
+			if p.NameID == sn.ID && !p.Invalid {
+				times = append(times, p.Duration)
+				ids = append(ids, p.ID)
+				runs = append(runs, 0)
+			}
+		}
+
+		for _, o := range stats.Outliers(times) {
+			found++
+
+			where := fmt.Sprintf("run %d", runs[o.Index])
+			kind := "segment"
+			setInvalid := storage.Splits().SetInvalid
+			if runs[o.Index] == 0 {
+				where = "practice"
+				kind = "practice segment"
+				setInvalid = storage.Practice().SetInvalid
+			}
+
+			fmt.Printf(
+				"%s in %s: %s (median %s, %.1f standard deviations faster)\n",
+				routeData.GetSplitName(i),
+				where,
+				o.Duration.Round(refreshInterval),
+				o.Median.Round(refreshInterval),
+				o.Deviations,
+			)
+			if !promptYN(fmt.Sprintf("Mark %s %d as invalid?", kind, ids[o.Index])) {
+				continue
+			}
+			if err := setInvalid(ids[o.Index], true); err != nil {
+				return err
+			}
+		}
+	}
+
+	if found == 0 {
+		fmt.Println("No outliers found")
+	}
+	return nil
+}
+
+// Marks a run, a single segment or a practice segment as invalid, or valid again with -restore.
+func invalidateCommand(args []string) error {
+	flags := flag.NewFlagSet("invalidate", flag.ExitOnError)
+	runID := flags.Int64("run", 0, "ID of the run to mark")
+	segmentID := flags.Int64("segment", 0, "ID of the segment to mark")
+	practiceID := flags.Int64("practice", 0, "ID of the practice segment to mark")
+	restore := flags.Bool("restore", false, "mark as valid again")
+	flags.Parse(args)
+
+	passed := 0
+	for _, id := range []int64{*runID, *segmentID, *practiceID} {
+		if id != 0 {
+			passed++
+		}
+	}
+
+	switch {
+	case passed > 1:
+		return errors.New("invalidate: pass one of -run, -segment or -practice")
+	case *runID != 0:
+		if err := storage.Runs().SetInvalid(*runID, !*restore); err != nil {
+			return err
+		}
+		fmt.Printf("Marked run %d as %s\n", *runID, validity(!*restore))
+	case *segmentID != 0:
+		if err := storage.Splits().SetInvalid(*segmentID, !*restore); err != nil {
+			return err
+		}
+		fmt.Printf("Marked segment %d as %s\n", *segmentID, validity(!*restore))
+	case *practiceID != 0:
+		if err := storage.Practice().SetInvalid(*practiceID, !*restore); err != nil {
+			return err
+		}
+		fmt.Printf("Marked practice segment %d as %s\n", *practiceID, validity(!*restore))
+	default:
+		return errors.New("invalidate: -run, -segment or -practice is required")
+	}
+	return nil
+}
+
+func validity(invalid bool) string {
+	if invalid {
+		return "invalid"
+	}
+	return "valid"
+}
@@ -0,0 +1,135 @@
+// Package journal persists the run that is in progress.
+// It lets a run survive gsplits crashing or the terminal closing.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+)
+
+// The name of the journal file.
+// Created as a hidden file in the home directory: ~/.gsplits.journal
+const fileName = ".gsplits.journal"
+
+// Run is a run that is in progress.
+type Run struct {
+	RouteID    int64
+	Tag        string          // The tag of the runs that the run is compared to. The run is saved with it.
+	Comparison int64           // The run that was picked as the comparison. Zero when it is the best run.
+	StartIndex int             // The split the run started from.
+	Offset     time.Duration   // The run time that the splits before StartIndex count as.
+	Start      time.Time       // When the run was started.
+	Segments   []time.Duration // The segments of the run. Zero until the segment is completed.
+	SplitTimes []time.Time     // When each segment was completed.
+	Events     []route.Event   // What happened on the timer during the run.
+	Paused     time.Duration   // Time the run spent stopped, such as between a crash and resuming.
+	UpdatedAt  time.Time       // When the journal was last written.
+}
+
+// Completed returns the amount of completed segments.
+func (r *Run) Completed() (count int) {
+	for _, s := range r.Segments[r.StartIndex:] {
+		if s == 0 {
+			break
+		}
+		count++
+	}
+	return
+}
+
+// IsDone returns whether every segment is completed.
+func (r *Run) IsDone() bool {
+	return len(r.Segments) > 0 && r.StartIndex+r.Completed() == len(r.Segments)
+}
+
+// Total returns the sum of the completed segments.
+// For a run from a later split it doesn't include Offset.
+func (r *Run) Total() (total time.Duration) {
+	for _, s := range r.Segments[r.StartIndex : r.StartIndex+r.Completed()] {
+		total += s
+	}
+	return
+}
+
+// Path returns the location of the journal file.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fileName), nil
+}
+
+// Save writes the run to the journal.
+// The previous journal is replaced only after the new one is fully written.
+func Save(r *Run) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	r.UpdatedAt = time.Now()
+	content, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	if _, err = f.Write(content); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Load reads the journal.
+// Returns nil when there isn't a run in progress.
+func Load() (*Run, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	r := new(Run)
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("failed to decode journal %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Clear removes the journal.
+func Clear() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear journal: %w", err)
+	}
+	return nil
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/knoebber/gsplits/db"
 	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
 	"github.com/rivo/tview"
 )
 
 var app *tview.Application
 
+// Where categories, routes and runs are kept.
+var storage store.Store
+
 func exit(err error) {
 	fmt.Println(err)
 	os.Exit(1)
@@ -29,7 +34,88 @@ func main() {
 
 	defer db.Close()
 
-	routeName := strings.TrimSpace(strings.Join(os.Args[1:], " "))
+	storage = store.NewSQLite(db.Connection)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "new-route":
+			if err = newRouteCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "replay":
+			if err = replayCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "report":
+			if err = reportCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "odds":
+			if err = oddsCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "practice-plan":
+			if err = practicePlanCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "outliers":
+			if err = outliersCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "invalidate":
+			if err = invalidateCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "notes":
+			if err = notesCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "runs":
+			if err = runsCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "category":
+			if err = categoryCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		case "site":
+			if err = siteCommand(os.Args[2:]); err != nil {
+				exit(err)
+			}
+			return
+		}
+	}
+
+	resumeData, resumeRun, err := recoverRun()
+	if err != nil {
+		exit(err)
+	}
+	if resumeRun != nil {
+		app = tview.NewApplication()
+		resumeTimer(resumeData, resumeRun)
+		if err := app.Run(); err != nil {
+			exit(err)
+		}
+		return
+	}
+
+	// Runs are compared against the runs with --tag, and saved with it.
+	flags := flag.NewFlagSet("gsplits", flag.ExitOnError)
+	tag := flags.String("tag", "", "compare against runs with the tag")
+	compare := flags.Int64("compare", 0, "ID of a saved run to compare against instead of the best run")
+	flags.Parse(os.Args[1:])
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
 
 	// Search for route name in database by the passed in name.
 	if routeName != "" {
@@ -37,7 +123,7 @@ func main() {
 		if err != nil {
 			exit(err)
 		}
-		routeData, err = route.GetData(routeID)
+		routeData, err = store.GetTaggedData(storage, routeID, *tag)
 		if err != nil {
 			exit(err)
 		}
@@ -48,7 +134,14 @@ func main() {
 			exit(err)
 		}
 
-		routeData, err = route.GetData(routeID)
+		routeData, err = store.GetTaggedData(storage, routeID, *tag)
+		if err != nil {
+			exit(err)
+		}
+	}
+
+	if *compare != 0 {
+		routeData, err = store.CompareToRun(storage, routeData, *compare)
 		if err != nil {
 			exit(err)
 		}
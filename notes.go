@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/rivo/tview"
+)
+
+// Writes the notes of a route as Markdown with a header for each split.
+func writeNotes(w io.Writer, routeData *route.Data) error {
+	for i, sn := range routeData.SplitNames {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n", sn.Name); err != nil {
+			return err
+		}
+		if sn.Notes != "" {
+			if _, err := fmt.Fprintf(w, "\n%s\n", sn.Notes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reads the notes of each split from Markdown.
+//
+// Each split's notes start with a top level header of its name, like writeNotes writes, and end at the next one.
+// Lower level headers, such as "## Strat A", are part of the notes.
+// Headers have to be in the same order as the route's splits and splits can be left out.
+// Text before the first header is ignored.
+// Returns the notes in the same order as the route's split names.
+func readNotes(r io.Reader, routeData *route.Data) ([]string, error) {
+	var (
+		notes   = make([]string, len(routeData.SplitNames))
+		lines   []string
+		current = -1
+	)
+
+	flush := func() {
+		if current >= 0 {
+			notes[current] = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, ok := splitHeader(line)
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+
+		flush()
+		next := -1
+		for i := current + 1; i < len(routeData.SplitNames); i++ {
+			if strings.EqualFold(routeData.SplitNames[i].Name, name) {
+				next = i
+				break
+			}
+		}
+		if next < 0 && current < 0 {
+			return nil, fmt.Errorf("%q isn't a split in %s", name, routeData.RouteName)
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("%q isn't a split after %q in %s", name, routeData.GetSplitName(current), routeData.RouteName)
+		}
+		current = next
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return notes, nil
+}
+
+// Returns the text of a top level Markdown ATX header, such as "# Whomp's Fortress".
+func splitHeader(line string) (string, bool) {
+	text := strings.TrimPrefix(line, "#")
+	if text == line || text != "" && text[0] != ' ' && text[0] != '\t' {
+		return "", false
+	}
+	return strings.TrimSpace(text), true
+}
+
+// Saves the notes of each split and updates routeData.
+// notes must be in the same order as the route's split names.
+func saveNotes(routeData *route.Data, notes []string) error {
+	for i, sn := range routeData.SplitNames {
+		if sn.Notes == notes[i] {
+			continue
+		}
+		if err := storage.Splits().SetNotes(sn.ID, notes[i]); err != nil {
+			return err
+		}
+		routeData.SplitNames[i].Notes = notes[i]
+	}
+	return nil
+}
+
+// Opens the notes of a route in the runner's editor and saves them when it exits.
+// The editor is $EDITOR, or vi when it isn't set.
+func editNotes(routeData *route.Data) error {
+	f, err := ioutil.TempFile("", "gsplits-notes-*.md")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := writeNotes(f, routeData); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", editor, err)
+	}
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	notes, err := readNotes(f, routeData)
+	if err != nil {
+		return err
+	}
+	return saveNotes(routeData, notes)
+}
+
+// Shows an error from editing notes.
+// back is called when the error is closed.
+func showNotesError(err error, back func()) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Failed to save notes: %s", err)).
+		AddButtons([]string{"Back"}).
+		SetDoneFunc(func(int, string) {
+			back()
+		})
+
+	app.SetRoot(modal, false).SetFocus(modal)
+}
+
+// Prints the notes of a route as Markdown, or replaces them with the notes in the file passed to --from.
+func notesCommand(args []string) error {
+	flags := flag.NewFlagSet("notes", flag.ExitOnError)
+	from := flags.String("from", "", "Markdown file with a header for each split")
+	edit := flags.Bool("edit", false, "edit the notes in $EDITOR")
+	flags.Parse(args)
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("notes: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := storage.Routes().GetData(routeID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *from != "" && *edit:
+		return errors.New("notes: pass either --from or --edit")
+	case *edit:
+		return editNotes(routeData)
+	case *from != "":
+		f, err := os.Open(*from)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		notes, err := readNotes(f, routeData)
+		if err != nil {
+			return fmt.Errorf("failed to read notes from %s: %w", *from, err)
+		}
+		return saveNotes(routeData, notes)
+	default:
+		return writeNotes(os.Stdout, routeData)
+	}
+}
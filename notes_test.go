@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+func notesTestData(notes ...string) *route.Data {
+	d := &route.Data{RouteName: "16 star"}
+	for i, name := range []string{"BOB", "WF", "CCM"} {
+		d.SplitNames = append(d.SplitNames, split.Name{ID: int64(i + 1), Name: name, Notes: notes[i]})
+	}
+	return d
+}
+
+func TestNotesRoundTrip(t *testing.T) {
+	d := notesTestData(
+		"## Strat A\nWall kick.\n\n## Strat B\nLong jump.",
+		"",
+		"Slide kick.\n### Backup\n#hashtag isn't a header",
+	)
+
+	var buf bytes.Buffer
+	if err := writeNotes(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := readNotes(&buf, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{d.SplitNames[0].Notes, d.SplitNames[1].Notes, d.SplitNames[2].Notes}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("readNotes(writeNotes()) = %q, want %q", notes, want)
+	}
+}
+
+func TestReadNotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "left out splits are cleared",
+			input: "Ignored\n# bob\nFirst\n#\tCCM\nLast\n",
+			want:  []string{"First", "", "Last"},
+		},
+		{
+			name:  "lower level headers are notes",
+			input: "# BOB\n## WF\n# WF\nNotes",
+			want:  []string{"## WF", "Notes", ""},
+		},
+		{
+			name:    "unknown split",
+			input:   "# BOB\n# Lethal Lava Land\n",
+			wantErr: true,
+		},
+		{
+			name:    "out of order",
+			input:   "# WF\n# BOB\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := readNotes(strings.NewReader(tt.input), notesTestData("old", "old", "old"))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readNotes() = %q, want an error", notes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(notes, tt.want) {
+				t.Errorf("readNotes() = %q, want %q", notes, tt.want)
+			}
+		})
+	}
+}
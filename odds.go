@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+	"github.com/knoebber/gsplits/stats"
+	"github.com/knoebber/gsplits/store"
+)
+
+// Returns the segment history of a route for simulating runs, from its runs and valid practice segments.
+// Only the runs with the route data's tag are used.
+// Practice segments aren't tagged, so they're left out when there is a tag.
+func getHistory(routeData *route.Data) ([][]time.Duration, error) {
+	_, durations, err := store.GetTaggedRuns(storage, routeData.RouteID, routeData.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if routeData.Tag == "" {
+		practice, err := storage.Practice().GetByRoute(routeData.RouteID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range practice {
+			if !p.Invalid {
+				durations = append(durations, split.Duration{NameID: p.NameID, Duration: p.Duration})
+			}
+		}
+	}
+	return stats.History(routeData.SplitNames, durations), nil
+}
+
+// Simulates runs of a route from the start.
+func getOdds(routeData *route.Data, simulations int, rnd *rand.Rand) (stats.Odds, error) {
+	var pb time.Duration
+
+	history, err := getHistory(routeData)
+	if err != nil {
+		return stats.Odds{}, err
+	}
+	if routeData.RouteBestTime != nil {
+		pb = *routeData.RouteBestTime
+	}
+	return stats.Simulate(history, 0, pb, simulations, rnd)
+}
+
+// Returns a one line summary of a route's odds for the preview.
+func oddsSummary(routeData *route.Data) string {
+	odds, err := getOdds(routeData, stats.DefaultSimulations, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err == stats.ErrNoHistory {
+		return "Complete a run to estimate PB odds"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("PB chance: %.1f%%, expected finish: %s", odds.PBChance*100, odds.Expected.Round(refreshInterval))
+}
+
+// Prints the estimated odds of beating a route's personal best.
+func oddsCommand(args []string) error {
+	flags := flag.NewFlagSet("odds", flag.ExitOnError)
+	simulations := flags.Int("n", stats.DefaultSimulations, "how many runs to simulate")
+	seed := flags.Int64("seed", 0, "random seed, defaults to the current time")
+	tag := flags.String("tag", "", "only simulate from runs with the tag")
+	flags.Parse(args)
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("odds: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := store.GetTaggedData(storage, routeID, *tag)
+	if err != nil {
+		return err
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	odds, err := getOdds(routeData, *simulations, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", routeData.Category.Name, routeData.RouteName)
+	fmt.Println(divider)
+	fmt.Printf("Simulated runs: %d\n", odds.Simulations)
+	fmt.Printf("Personal best: %s\n", strings.TrimSpace(safeDurationStr(routeData.RouteBestTime)))
+	fmt.Printf("PB chance: %.2f%%\n", odds.PBChance*100)
+	fmt.Printf("Expected finish: %s\n", odds.Expected.Round(refreshInterval))
+	fmt.Println(divider)
+	for _, p := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"10%", odds.P10},
+		{"25%", odds.P25},
+		{"50%", odds.Median},
+		{"75%", odds.P75},
+		{"90%", odds.P90},
+	} {
+		fmt.Printf("%s of runs finish under %s\n", p.name, p.value.Round(refreshInterval))
+	}
+	return nil
+}
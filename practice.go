@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+	"github.com/knoebber/gsplits/stats"
+	"github.com/knoebber/gsplits/store"
+	"github.com/knoebber/gsplits/timer"
+	"github.com/rivo/tview"
+)
+
+// Returns the route's segments ranked by how much practicing them is expected to save.
+func getRecommendations(routeData *route.Data) ([]stats.Recommendation, error) {
+	history, err := getHistory(routeData)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Recommend(routeData, history), nil
+}
+
+// Prints the segments of a route in the order they should be practiced.
+func practicePlanCommand(args []string) error {
+	flags := flag.NewFlagSet("practice-plan", flag.ExitOnError)
+	tag := flags.String("tag", "", "only rank from runs with the tag")
+	flags.Parse(args)
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("practice-plan: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := store.GetTaggedData(storage, routeID, *tag)
+	if err != nil {
+		return err
+	}
+	recommendations, err := getRecommendations(routeData)
+	if err != nil {
+		return err
+	}
+	if len(recommendations) == 0 {
+		return fmt.Errorf("practice-plan: %s has no runs", routeData.RouteName)
+	}
+
+	fmt.Printf("%s: %s\n", routeData.Category.Name, routeData.RouteName)
+	fmt.Println("Segments ordered by expected time save from practice")
+	fmt.Println(divider)
+	for i, r := range recommendations {
+		fmt.Printf(
+			"%d.) %s: %s (possible save %s, loses time %.0f%% of the time, std dev %s)\n",
+			i+1,
+			r.Name,
+			r.Score.Round(refreshInterval),
+			r.TimeSave.Round(refreshInterval),
+			r.LossRate*100,
+			r.StdDev.Round(refreshInterval),
+		)
+	}
+	return nil
+}
+
+// Asks which splits to practice and starts practicing them.
+// cancel is called when the runner decides not to practice.
+func showPracticeForm(routeData *route.Data, cancel func()) {
+	var from, to int
+
+	names := make([]string, len(routeData.SplitNames))
+	for i := range routeData.SplitNames {
+		names[i] = routeData.GetSplitName(i)
+	}
+
+	form := tview.NewForm().
+		AddDropDown("From", names, 0, func(_ string, i int) { from = i }).
+		AddDropDown("To", names, 0, func(_ string, i int) { to = i })
+
+	form.
+		AddButton("Practice", func() {
+			if to < from {
+				from, to = to, from
+			}
+			startPractice(routeData, from, to)
+		}).
+		AddButton("Cancel", cancel)
+
+	form.SetBorder(true).SetTitle("Practice splits")
+	app.SetRoot(form, true).SetFocus(form)
+}
+
+// Times the splits from index from to index to over and over.
+// Each completed segment is saved as a practice segment when an attempt ends.
+// q goes back to the preview of routeData.
+func startPractice(routeData *route.Data, from, to int) {
+	state := newTimerState(timer.New(routeData.Range(from, to), timer.SystemClock))
+	state.practice = true
+	state.statusView.SetText("Practicing: space splits, r starts over, q goes back")
+
+	container := state.createLayout()
+	state.startRefresh()
+	app.SetRoot(container, true).SetInputCapture(getPracticeInputHandler(state, routeData))
+}
+
+// Goes back to the preview from practice.
+// The route is loaded again so that the preview has the golds from practice.
+func leavePractice(routeData *route.Data) {
+	reloaded, err := store.GetTaggedData(storage, routeData.RouteID, routeData.Tag)
+	if err == nil && routeData.ComparisonRun != nil {
+		reloaded, err = store.CompareToRun(storage, reloaded, routeData.ComparisonRun.ID)
+	}
+	if err == nil {
+		app.SetInputCapture(nil)
+		err = setPreview(reloaded)
+	}
+	if err != nil {
+		app.Stop()
+		exit(err)
+	}
+}
+
+// Saves the completed segments of the practice attempt.
+// A faster segment becomes the gold for the rest of the practice session,
+// and the sum of gold that the timer starts from on reset.
+func savePractice(state *timerState) {
+	routeData := state.timer.RouteData()
+	splitTimes := state.timer.SplitTimes()
+
+	for i, segment := range state.timer.Segments() {
+		if segment == 0 {
+			continue
+		}
+
+		_, err := storage.Practice().Save(&split.Practice{
+			NameID:   routeData.SplitNames[i].ID,
+			Duration: segment,
+			EndedAt:  splitTimes[i],
+		})
+		if err != nil {
+			state.showError(err)
+			return
+		}
+
+		if i < len(routeData.Golds) && segment < routeData.Golds[i] {
+			routeData.TimeSaves[i] += routeData.Golds[i] - segment
+			if routeData.SumOfGold != nil {
+				*routeData.SumOfGold -= routeData.Golds[i] - segment
+			}
+			routeData.Golds[i] = segment
+		}
+	}
+}
+
+func getPracticeInputHandler(state *timerState, routeData *route.Data) func(event *tcell.EventKey) *tcell.EventKey {
+	restart := func() {
+		savePractice(state)
+		state.timer.Reset()
+	}
+
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'r':
+			restart()
+			return nil
+
+		case ' ':
+			// Start the next attempt once the range is done.
+			if state.timer.IsDone() {
+				restart()
+			} else {
+				state.timer.Split()
+			}
+			return nil
+
+		case 'p':
+			state.togglePrediction()
+			return nil
+
+		case 'q':
+			savePractice(state)
+			state.endRefresh()
+			leavePractice(routeData)
+			return nil
+		}
+
+		switch event.Key() {
+		case tcell.KeyCtrlSpace:
+			state.timer.Undo()
+		}
+		return event
+	}
+}
@@ -8,13 +8,32 @@ import (
 	"github.com/rivo/tview"
 )
 
-func showPreview(routeData *route.Data) (err error) {
+// Shows the preview of a route and runs the app until it's quit.
+func showPreview(routeData *route.Data) error {
+	if err := setPreview(routeData); err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// Sets the app to the preview of a route.
+func setPreview(routeData *route.Data) (err error) {
 	var (
 		title string
 		best  string
 	)
 
 	title = fmt.Sprintf("%s: %s", routeData.Category.Name, routeData.RouteName)
+	if routeData.Category.Game != "" {
+		title = fmt.Sprintf("%s - %s", routeData.Category.Game, title)
+	}
+	title += variablesSuffix(routeData.Category.Variables)
+	if routeData.Tag != "" {
+		title += fmt.Sprintf(", compared to runs tagged %s", routeData.Tag)
+	}
+	if run := routeData.ComparisonRun; run != nil {
+		title += fmt.Sprintf(", compared to run %d from %s", run.ID, run.CreatedAt.Local().Format("Jan 2 2006"))
+	}
 	if routeData.Category.Best != nil {
 		best = fmt.Sprintf("%s Best: %s", routeData.Category.Name, *routeData.Category.Best)
 		if routeData.RouteBestTime != nil && *routeData.Category.Best < *routeData.RouteBestTime {
@@ -25,11 +44,28 @@ func showPreview(routeData *route.Data) (err error) {
 		best = "No runs yet"
 	}
 
+	// The rank of each split in the practice plan.
+	practiceRanks := map[int]int{}
+	recommendations, err := getRecommendations(routeData)
+	if err != nil {
+		return err
+	}
+	for rank, r := range recommendations {
+		practiceRanks[r.Index] = rank + 1
+	}
+
 	table := newTable()
 
+	// The comparison columns are labeled with the run they're from when it isn't the best run.
+	splitHeader, segmentHeader := "Split Time", "Segment Duration"
+	if run := routeData.ComparisonRun; run != nil {
+		splitHeader = fmt.Sprintf("Run %d Split", run.ID)
+		segmentHeader = fmt.Sprintf("Run %d Segment", run.ID)
+	}
+
 	onTableFocus := func(focus bool) {
 		for col, value := range []string{
-			"Name", "Split Time", "Segment Duration", "Gold", "Possible Save",
+			"Name", splitHeader, segmentHeader, "Gold", "Possible Save", "Practice",
 		} {
 
 			if focus {
@@ -44,12 +80,18 @@ func showPreview(routeData *route.Data) (err error) {
 	onTableFocus(true)
 
 	for i := range routeData.SplitNames {
+		practiceRank := ""
+		if rank, ok := practiceRanks[i]; ok {
+			practiceRank = fmt.Sprintf("#%d", rank)
+		}
+
 		for j, value := range []string{
 			routeData.GetSplitName(i),
 			durationStr(routeData.GetComparisonSplit(i)),
 			durationStr(routeData.GetComparisonSegment(i)),
 			durationStr(routeData.GetGold(i)),
 			durationStr(routeData.GetTimeSave(i)),
+			practiceRank,
 		} {
 			setTableCell(table, i+1, j, value, tcell.ColorDefault)
 		}
@@ -71,13 +113,46 @@ func showPreview(routeData *route.Data) (err error) {
 		startTimer(routeData)
 	})
 
-	startButton.SetBlurFunc(func(key tcell.Key) {
+	practiceButton := newButton("Practice")
+	startFromButton := newButton("Start From")
+	notesButton := newButton("Notes")
+	compareButton := newButton("Compare")
+
+	startFromButton.SetBlurFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyTab:
+			app.SetFocus(practiceButton)
+		}
+	})
+
+	practiceButton.SetBlurFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyTab:
+			app.SetFocus(notesButton)
+		}
+	})
+
+	notesButton.SetBlurFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyTab:
+			app.SetFocus(compareButton)
+		}
+	})
+
+	compareButton.SetBlurFunc(func(key tcell.Key) {
 		switch key {
 		case tcell.KeyTab:
 			app.SetFocus(quitButton)
 		}
 	})
 
+	startButton.SetBlurFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyTab:
+			app.SetFocus(startFromButton)
+		}
+	})
+
 	table.SetFixed(1, 1)
 	table.SetDoneFunc(func(key tcell.Key) {
 		switch key {
@@ -94,13 +169,47 @@ func showPreview(routeData *route.Data) (err error) {
 	flex := tview.NewFlex().SetDirection(tview.FlexRow).SetFullScreen(true).
 		AddItem(newText(title), 0, 1, false).
 		AddItem(newText(best), 0, 1, false).
+		AddItem(newText(oddsSummary(routeData)), 0, 1, false).
 		AddItem(table, 0, 8, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow), 0, 1, false).
 		AddItem(tview.NewFlex().
 			AddItem(startButton, 10, 1, false).
 			AddItem(nil, 7, 2, false).
+			AddItem(startFromButton, 14, 1, false).
+			AddItem(nil, 7, 2, false).
+			AddItem(practiceButton, 12, 1, false).
+			AddItem(nil, 7, 2, false).
+			AddItem(notesButton, 9, 1, false).
+			AddItem(nil, 7, 2, false).
+			AddItem(compareButton, 11, 1, false).
+			AddItem(nil, 7, 2, false).
 			AddItem(quitButton, 10, 1, false),
 			0, 1, true)
 
-	return app.SetRoot(flex, true).SetFocus(table).Run()
+	back := func() {
+		onTableFocus(true)
+		app.SetRoot(flex, true).SetFocus(table)
+	}
+	startFromButton.SetSelectedFunc(func() {
+		showStartFromForm(routeData, back)
+	})
+	practiceButton.SetSelectedFunc(func() {
+		showPracticeForm(routeData, back)
+	})
+	compareButton.SetSelectedFunc(func() {
+		if err := showComparePicker(routeData, back); err != nil {
+			app.Stop()
+			exit(err)
+		}
+	})
+	notesButton.SetSelectedFunc(func() {
+		var err error
+		app.Suspend(func() { err = editNotes(routeData) })
+		if err != nil {
+			showNotesError(err, back)
+		}
+	})
+
+	app.SetRoot(flex, true).SetFocus(table)
+	return nil
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/knoebber/gsplits/journal"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
+)
+
+// Offers to resume, save or discard a run that was in progress when gsplits last exited.
+// Returns the route data and journaled run when the user chooses to resume it.
+func recoverRun() (*route.Data, *journal.Run, error) {
+	run, err := journal.Load()
+	if err != nil || run == nil {
+		return nil, nil, err
+	}
+
+	// The journal is kept when the route can't be loaded for any other reason, such as a locked database.
+	routeData, err := store.GetTaggedData(storage, run.RouteID, run.Tag)
+	if errors.Is(err, route.ErrNotFound) {
+		fmt.Printf("Discarding unfinished run of a missing route: %s\n", err)
+		return nil, nil, journal.Clear()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the route of the unfinished run: %w", err)
+	}
+	if run.Comparison != 0 {
+		compared, err := store.CompareToRun(storage, routeData, run.Comparison)
+		if err != nil {
+			// The run was deleted or changed since; the best run is still a comparison.
+			fmt.Printf("Comparing to the best run instead of run %d: %s\n", run.Comparison, err)
+		} else {
+			routeData = compared
+		}
+	}
+
+	fmt.Printf(
+		"Found an unfinished run of %s: %s from %s\n",
+		routeData.Category.Name,
+		routeData.RouteName,
+		run.Start.Format("Jan 2 15:04"),
+	)
+	fmt.Printf("Completed %d/%d splits in %s\n", run.Completed(), len(run.Segments)-run.StartIndex, durationStr(run.Total()))
+
+	options := []string{"Resume", "Discard"}
+	if run.IsDone() {
+		options = append(options, "Save")
+	} else if run.Completed() > 0 {
+		options = append(options, "Save as partial")
+	}
+	for i, option := range options {
+		fmt.Printf("(%d) %s\n", i+1, option)
+	}
+
+	switch options[promptListSelect(len(options))] {
+	case "Resume":
+		return routeData, run, nil
+	case "Save", "Save as partial":
+		if _, err := saveRun(run, "", []string{run.Tag}, nil); err != nil {
+			return nil, nil, err
+		}
+		fmt.Println("Saved run")
+	}
+	return nil, nil, journal.Clear()
+}
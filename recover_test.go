@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/journal"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
+)
+
+// Points the journal at a new home directory and journals a run of routeID.
+// Returns a function that restores the home directory.
+func journalTestRun(t *testing.T, routeID int64) func() {
+	t.Helper()
+
+	home, err := ioutil.TempDir("", "gsplits-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+
+	if err := journal.Save(&journal.Run{
+		RouteID:  routeID,
+		Start:    time.Now(),
+		Segments: []time.Duration{time.Second, 0},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		os.Setenv("HOME", oldHome)
+		os.RemoveAll(home)
+	}
+}
+
+func TestRecoverRunMissingRoute(t *testing.T) {
+	defer journalTestRun(t, 9999)()
+	storage = store.NewMemory()
+
+	if _, run, err := recoverRun(); run != nil || err != nil {
+		t.Fatalf("recoverRun() = %v, %v, want the run discarded", run, err)
+	}
+	if run, err := journal.Load(); run != nil || err != nil {
+		t.Errorf("journal of a missing route = %v, %v, want it cleared", run, err)
+	}
+}
+
+func TestRecoverRunKeepsJournalOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsplits-recover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := db.Open(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage = store.NewSQLite(conn)
+	routeID, err := storage.Routes().SaveWithCategory(&category.Name{Name: "category"}, &route.Name{Name: "route"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journalTestRun(t, routeID)()
+
+	// Loading the route fails for a reason other than it being deleted.
+	conn.Close()
+	if _, _, err := recoverRun(); err == nil {
+		t.Fatal("recoverRun() didn't return the error loading the route")
+	}
+	if run, err := journal.Load(); run == nil || err != nil {
+		t.Errorf("journal after a failed load = %v, %v, want it kept", run, err)
+	}
+}
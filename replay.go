@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
+	"github.com/knoebber/gsplits/timer"
+	"github.com/rivo/tview"
+)
+
+// Replays a saved run of a route in the timer view.
+// The route's best run is replayed unless --run is passed.
+// With --tag the comparison and default run come from the runs with the tag.
+// With --compare the replay is compared to another saved run.
+func replayCommand(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	runID := flags.Int64("run", 0, "ID of the run to replay, defaults to the route's best run")
+	speed := flags.Float64("speed", 1, "how many times faster than real time to replay")
+	tag := flags.String("tag", "", "compare against runs with the tag")
+	compare := flags.Int64("compare", 0, "ID of a saved run to compare against instead of the best run")
+	flags.Parse(args)
+
+	if *speed <= 0 {
+		return errors.New("replay: --speed must be greater than zero")
+	}
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("replay: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := store.GetTaggedData(storage, routeID, *tag)
+	if err != nil {
+		return err
+	}
+
+	if *runID == 0 {
+		*runID = routeData.BestRunID
+	}
+	if *runID == 0 {
+		return fmt.Errorf("replay: %s has no runs", routeData.RouteName)
+	}
+
+	if *compare != 0 {
+		if routeData, err = store.CompareToRun(storage, routeData, *compare); err != nil {
+			return err
+		}
+	}
+
+	run, err := storage.Runs().Get(*runID)
+	if err != nil {
+		return err
+	}
+	if run == nil || run.RouteID != routeID {
+		return fmt.Errorf("replay: run %d isn't in %s", *runID, routeData.RouteName)
+	}
+
+	durations, err := storage.Runs().GetSegments(run.ID)
+	if err != nil {
+		return err
+	}
+	segments := make([]time.Duration, len(durations))
+	for i, d := range durations {
+		segments[i] = d.Duration
+	}
+
+	app = tview.NewApplication()
+	replayTimer(routeData, run.StartIndex, segments, *speed)
+	return app.Run()
+}
+
+// Starts the timer view and splits it at the end of each segment.
+// The comparison is the route's current comparison, so deltas are shown as they would look live.
+// Partial runs are replayed from startIndex. Replays aren't journaled or saved.
+func replayTimer(routeData *route.Data, startIndex int, segments []time.Duration, speed float64) {
+	clock := timer.NewReplayClock(speed)
+	state := newTimerState(timer.New(routeData, clock))
+	state.timer.StartFrom(startIndex, 0)
+	state.setSplitsTable()
+	state.replay = true
+	state.statusView.SetText(fmt.Sprintf("Replaying at %gx, press q to quit", speed))
+
+	stop := make(chan struct{})
+	go timer.Replay(state.timer, clock, segments, stop)
+
+	container := state.createLayout()
+	state.startRefresh()
+	app.SetRoot(container, true).SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'q' || event.Key() == tcell.KeyEscape {
+			close(stop)
+			state.endRefresh()
+			app.Stop()
+			return nil
+		}
+		if event.Rune() == 'p' {
+			state.togglePrediction()
+			return nil
+		}
+		return event
+	})
+}
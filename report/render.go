@@ -0,0 +1,111 @@
+package report
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var funcs = template.FuncMap{
+	"duration": FormatDuration,
+	"date":     FormatDate,
+	"cell":     markdownCell,
+}
+
+// FormatDuration formats a duration to the millisecond, or a dash when there is no time.
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// FormatDate formats the date and minute of t in local time.
+func FormatDate(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// Escapes the pipes in a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
+
+var markdown = template.Must(template.New("markdown").Funcs(funcs).Parse(
+	`# {{with .Data.Category.Game}}{{.}} - {{end}}{{.Data.Category.Name}}: {{.Data.RouteName}}
+
+Generated {{date .GeneratedAt}}{{with .Tag}} from runs tagged {{.}}{{end}}
+
+| | |
+|---|---|
+{{with .Data.Category.Variables}}| Variables | {{cell .String}} |
+{{end}}| Personal best | {{with .Data.RouteBestTime}}{{duration .}}{{else}}-{{end}} |
+| Sum of best | {{with .Data.SumOfGold}}{{duration .}}{{else}}-{{end}} |
+| Attempts | {{.Attempts}}{{if .Tag}} (resets not counted){{end}} |
+| Completed runs | {{.Runs}} |
+| Resets | {{if .Tag}}not counted{{else}}{{.Resets}}{{end}} |
+
+## Splits
+
+{{if .Tag}}Practice segments aren't tagged, so they aren't counted.
+
+{{end}}| Split | PB split | PB segment | Gold | Mean | Median | Std dev | Worst | Count | Practice best | Practice mean | Practice count |
+|---|---|---|---|---|---|---|---|---|---|---|---|
+{{range .Splits}}| {{cell .Name}} | {{duration .PBSplit}} | {{duration .PBSegment}} | {{duration .Gold}} | {{duration .Mean}} | {{duration .Median}} | {{duration .StdDev}} | {{duration .Worst}} | {{.Count}} | {{duration .Practice.Best}} | {{duration .Practice.Mean}} | {{.Practice.Count}} |
+{{end}}
+## PB progression
+
+| Date | Run | Time | Improvement |
+|---|---|---|---|
+{{range .Progression}}| {{date .Date}} | {{.RunID}} | {{duration .Time}} | {{duration .Improvement}} |
+{{end}}`))
+
+var html = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{with .Data.Category.Game}}{{.}} - {{end}}{{.Data.Category.Name}}: {{.Data.RouteName}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{with .Data.Category.Game}}{{.}} - {{end}}{{.Data.Category.Name}}: {{.Data.RouteName}}</h1>
+<p>Generated {{date .GeneratedAt}}{{with .Tag}} from runs tagged {{.}}{{end}}</p>
+<table>
+{{with .Data.Category.Variables}}<tr><td>Variables</td><td>{{.}}</td></tr>
+{{end}}<tr><td>Personal best</td><td>{{with .Data.RouteBestTime}}{{duration .}}{{else}}-{{end}}</td></tr>
+<tr><td>Sum of best</td><td>{{with .Data.SumOfGold}}{{duration .}}{{else}}-{{end}}</td></tr>
+<tr><td>Attempts</td><td>{{.Attempts}}{{if .Tag}} (resets not counted){{end}}</td></tr>
+<tr><td>Completed runs</td><td>{{.Runs}}</td></tr>
+<tr><td>Resets</td><td>{{if .Tag}}not counted{{else}}{{.Resets}}{{end}}</td></tr>
+</table>
+<h2>Splits</h2>
+{{if .Tag}}<p>Practice segments aren't tagged, so they aren't counted.</p>
+{{end}}<table>
+<tr><th>Split</th><th>PB split</th><th>PB segment</th><th>Gold</th><th>Mean</th><th>Median</th><th>Std dev</th><th>Worst</th><th>Count</th><th>Practice best</th><th>Practice mean</th><th>Practice count</th></tr>
+{{range .Splits}}<tr><td>{{.Name}}</td><td>{{duration .PBSplit}}</td><td>{{duration .PBSegment}}</td><td>{{duration .Gold}}</td><td>{{duration .Mean}}</td><td>{{duration .Median}}</td><td>{{duration .StdDev}}</td><td>{{duration .Worst}}</td><td>{{.Count}}</td><td>{{duration .Practice.Best}}</td><td>{{duration .Practice.Mean}}</td><td>{{.Practice.Count}}</td></tr>
+{{end}}</table>
+<h2>PB progression</h2>
+<table>
+<tr><th>Date</th><th>Run</th><th>Time</th><th>Improvement</th></tr>
+{{range .Progression}}<tr><td>{{date .Date}}</td><td>{{.RunID}}</td><td>{{duration .Time}}</td><td>{{duration .Improvement}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Markdown writes the report as a Markdown document.
+func (r *Report) Markdown(w io.Writer) error {
+	return markdown.Execute(w, r)
+}
+
+// HTML writes the report as a standalone HTML page.
+func (r *Report) HTML(w io.Writer) error {
+	return html.Execute(w, r)
+}
@@ -0,0 +1,139 @@
+// Package report renders statistics about a route as Markdown or HTML.
+package report
+
+import (
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+	"github.com/knoebber/gsplits/stats"
+	"github.com/knoebber/gsplits/store"
+)
+
+// Report is the statistics of a route.
+type Report struct {
+	Data        *route.Data
+	GeneratedAt time.Time
+	Tag         string // When set, only runs with the tag are counted.
+	Runs        int    // The amount of full, valid runs. Partial, unfinished and invalid runs aren't counted.
+	Resets      int    // The amount of runs that were reset. Resets aren't tagged, so they're only counted without a tag.
+	Attempts    int    // Every saved run and reset.
+	Splits      []Split
+	Progression []PB // Every run that was a personal best when it finished, oldest first.
+}
+
+// Split is the statistics of a split in the route.
+type Split struct {
+	Name      string
+	PBSplit   time.Duration // The total time of the personal best at the split.
+	PBSegment time.Duration // The segment of the personal best.
+	Gold      time.Duration
+	Practice  stats.Summary // The segments timed in practice mode.
+	stats.Summary
+}
+
+// PB is a run that beat the route's best time.
+type PB struct {
+	RunID       int64
+	Date        time.Time
+	Time        time.Duration
+	Improvement time.Duration // How much faster it was than the previous best. Zero for the first run.
+}
+
+// Build gathers the statistics of a route from s.
+// When tag isn't empty only the runs with the tag are counted, and resets and practice segments are left out.
+func Build(s store.Store, routeID int64, tag string) (*Report, error) {
+	var (
+		events   []route.Event
+		practice []split.Practice
+	)
+
+	d, err := store.GetTaggedData(s, routeID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, durations, err := store.GetTaggedRuns(s, routeID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag == "" {
+		events, err = s.Events().GetByRoute(routeID)
+		if err != nil {
+			return nil, err
+		}
+
+		practice, err = s.Practice().GetByRoute(routeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Report{
+		Data:        d,
+		GeneratedAt: time.Now(),
+		Tag:         tag,
+	}
+	for _, run := range runs {
+		if run.IsFull() && !run.Invalid {
+			r.Runs++
+		}
+	}
+
+	for _, e := range events {
+		if e.Type == "reset" {
+			r.Resets++
+		}
+	}
+	r.Attempts = len(runs) + r.Resets
+
+	segments := map[int64][]time.Duration{}
+	for _, duration := range durations {
+		segments[duration.NameID] = append(segments[duration.NameID], duration.Duration)
+	}
+	practiceSegments := map[int64][]time.Duration{}
+	for _, p := range practice {
+		practiceSegments[p.NameID] = append(practiceSegments[p.NameID], p.Duration)
+	}
+	for i, sn := range d.SplitNames {
+		r.Splits = append(r.Splits, Split{
+			Name:      sn.Name,
+			PBSplit:   d.GetComparisonSplit(i),
+			PBSegment: d.GetComparisonSegment(i),
+			Gold:      d.GetGold(i),
+			Practice:  stats.Summarize(practiceSegments[sn.ID]),
+			Summary:   stats.Summarize(segments[sn.ID]),
+		})
+	}
+
+	r.Progression = Progression(runs)
+	return r, nil
+}
+
+// Progression returns the runs that were a personal best when they finished.
+// runs must be in the order they were saved. Partial, unfinished and invalid runs are left out.
+func Progression(runs []route.Run) []PB {
+	var (
+		result []PB
+		best   time.Duration
+	)
+
+	for _, run := range runs {
+		if !run.IsFull() || run.Invalid || len(result) > 0 && run.Duration >= best {
+			continue
+		}
+
+		pb := PB{RunID: run.ID, Date: run.StartedAt, Time: run.Duration}
+		if pb.Date.IsZero() {
+			pb.Date = run.CreatedAt
+		}
+		if len(result) > 0 {
+			pb.Improvement = best - run.Duration
+		}
+
+		best = run.Duration
+		result = append(result, pb)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+	"github.com/knoebber/gsplits/store"
+)
+
+func TestBuild(t *testing.T) {
+	s := store.NewMemory()
+	routeID, err := s.Routes().SaveWithCategory(&category.Name{Name: "category"}, &route.Name{Name: "route"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	saveRun := func(day int, run route.Run, segments ...time.Duration) int64 {
+		t.Helper()
+
+		run.RouteID = routeID
+		run.StartedAt = start.AddDate(0, 0, day)
+		durations := make([]split.Duration, len(segments))
+		for i, segment := range segments {
+			run.Duration += segment
+			durations[i].Duration = segment
+		}
+		runID, err := s.Runs().Save(&run, durations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runID
+	}
+
+	firstID := saveRun(0, route.Run{}, 6*time.Second, 4*time.Second)
+	saveRun(1, route.Run{StartIndex: 1}, time.Second)
+	invalidID := saveRun(2, route.Run{}, 5*time.Second, 4*time.Second)
+	saveRun(3, route.Run{}, 6*time.Second, 5*time.Second)
+	bestID := saveRun(4, route.Run{}, 5*time.Second, 3*time.Second)
+	saveRun(5, route.Run{Unfinished: true}, 2*time.Second)
+	if err := s.Runs().SetInvalid(invalidID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Events().Save(&route.Event{RouteID: routeID, Type: "reset", Time: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Build(s, routeID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Runs != 3 || r.Resets != 1 || r.Attempts != 7 {
+		t.Errorf("runs = %d, resets = %d, attempts = %d, want 3, 1 and 7", r.Runs, r.Resets, r.Attempts)
+	}
+
+	want := []PB{
+		{RunID: firstID, Date: start, Time: 10 * time.Second},
+		{RunID: bestID, Date: start.AddDate(0, 0, 4), Time: 8 * time.Second, Improvement: 2 * time.Second},
+	}
+	if !reflect.DeepEqual(r.Progression, want) {
+		t.Errorf("progression = %+v, want %+v", r.Progression, want)
+	}
+	if r.Splits[0].PBSegment != 5*time.Second || r.Splits[1].PBSplit != 8*time.Second {
+		t.Errorf("splits = %+v, want the segments of run %d", r.Splits, bestID)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/knoebber/gsplits/report"
+)
+
+// Writes a statistics report of a route to stdout or the file passed to -o.
+func reportCommand(args []string) (err error) {
+	var out io.Writer = os.Stdout
+
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	format := flags.String("format", "markdown", "markdown or html")
+	output := flags.String("o", "", "file to write the report to, defaults to stdout")
+	tag := flags.String("tag", "", "only count runs with the tag")
+	flags.Parse(args)
+
+	if *format != "markdown" && *format != "html" {
+		return fmt.Errorf("report: unknown format %#v", *format)
+	}
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("report: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	r, err := report.Build(storage, routeID, *tag)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = f
+	}
+
+	if *format == "html" {
+		return r.HTML(out)
+	}
+	return r.Markdown(out)
+}
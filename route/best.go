@@ -0,0 +1,66 @@
+package route
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SaveBests updates the golds and the route's best run with a newly saved run.
+// A run only replaces the best run when it's faster, so ties go to the earliest run.
+// Partial and unfinished runs can set golds but are never the best run.
+// The comparison is always every segment of that one run.
+func SaveBests(tx *sql.Tx, runID int64) error {
+	_, err := tx.Exec(`
+INSERT INTO gold(split_name_id, nanoseconds)
+SELECT split_name_id, nanoseconds FROM split WHERE run_id = ? AND nanoseconds IS NOT NULL AND NOT invalid
+ON CONFLICT(split_name_id) DO UPDATE SET nanoseconds = excluded.nanoseconds
+WHERE excluded.nanoseconds < gold.nanoseconds`, runID)
+	if err != nil {
+		return fmt.Errorf("failed to update golds: %w", err)
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO route_best(route_id, run_id, nanoseconds)
+SELECT route_id, id, nanoseconds FROM run WHERE id = ? AND nanoseconds IS NOT NULL AND start_index = 0 AND NOT unfinished AND NOT invalid
+ON CONFLICT(route_id) DO UPDATE SET run_id = excluded.run_id, nanoseconds = excluded.nanoseconds
+WHERE excluded.nanoseconds < route_best.nanoseconds`, runID)
+	if err != nil {
+		return fmt.Errorf("failed to update route best: %w", err)
+	}
+	return nil
+}
+
+// RecomputeBests rebuilds the golds and best run of a route from its valid runs, segments and practice.
+// It is for when runs or segments stop or start counting, since SaveBests can only make bests faster.
+func RecomputeBests(tx *sql.Tx, routeID int64) error {
+	statements := []string{
+		`DELETE FROM gold WHERE split_name_id IN (SELECT id FROM split_name WHERE route_id = ?1)`,
+		`INSERT INTO gold(split_name_id, nanoseconds)
+SELECT split_name_id, MIN(nanoseconds) FROM (
+  SELECT s.split_name_id, s.nanoseconds
+  FROM split AS s
+  JOIN run ON run.id = s.run_id
+  WHERE run.route_id = ?1 AND s.nanoseconds IS NOT NULL AND NOT s.invalid AND NOT run.invalid
+  UNION ALL
+  SELECT p.split_name_id, p.nanoseconds
+  FROM practice AS p
+  JOIN split_name AS sn ON sn.id = p.split_name_id
+  WHERE sn.route_id = ?1 AND NOT p.invalid
+)
+GROUP BY split_name_id`,
+		`DELETE FROM route_best WHERE route_id = ?1`,
+		`INSERT INTO route_best(route_id, run_id, nanoseconds)
+SELECT route_id, id, nanoseconds
+FROM run
+WHERE route_id = ?1 AND nanoseconds IS NOT NULL AND start_index = 0 AND NOT unfinished AND NOT invalid
+ORDER BY nanoseconds, id
+LIMIT 1`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, routeID); err != nil {
+			return fmt.Errorf("failed to recompute bests: %w", err)
+		}
+	}
+	return nil
+}
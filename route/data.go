@@ -11,6 +11,9 @@ import (
 	"github.com/knoebber/gsplits/split"
 )
 
+// ErrNotFound is returned when loading the data of a route that doesn't exist.
+var ErrNotFound = errors.New("route not found")
+
 // Data contains information about an route.
 // Array values should be pulled by their get methods.
 // Get methods return a zero value if the index does not exist.
@@ -19,6 +22,7 @@ type Data struct {
 	RouteID            int64           // The routes ID.
 	Category           *category.Name  // The routes category.
 	RouteBestTime      *time.Duration  // The fastest time this route has been completed.
+	BestRunID          int64           // The run that RouteBestTime and the comparison are from. Zero when there are no runs.
 	TotalRuns          int64           // The total amount of runs in this route.
 	SumOfGold          *time.Duration  // The sum of the gold splits.
 	SplitNames         []split.Name    // The names of the splits in the category.
@@ -27,6 +31,8 @@ type Data struct {
 	Golds              []time.Duration // The fastest a split has ever been completed in the route.
 	TimeSaves          []time.Duration // The difference of a gold and the route best
 	Length             int             // The number of splits in the route.
+	Tag                string          // When set, the comparison and golds only come from runs with the tag.
+	ComparisonRun      *Run            // The run that the comparison is from when it was picked instead of the best run.
 }
 
 // GetBPT gets the "best possible time" - assuming the user doesn't beat any golds.
@@ -48,6 +54,38 @@ func (d *Data) GetBPT(splitIndex int, lastSplit, currentDiff time.Duration) (bpt
 	return
 }
 
+// GetPredictedTime gets the time that a run finishes in when the rest of it is at the comparison's pace.
+// The active segment counts as the longer of segmentElapsed and the comparison segment, so falling behind moves the prediction right away.
+// When fromGolds is true the remaining segments are golds instead, which is the pace of the sum of best.
+// Returns zero when there is no comparison, or when fromGolds is true and a remaining split has no gold.
+func (d *Data) GetPredictedTime(splitIndex int, lastSplit, segmentElapsed time.Duration, fromGolds bool) time.Duration {
+	remaining := d.ComparisonSegments
+	if fromGolds {
+		remaining = d.Golds
+	}
+	if splitIndex >= len(remaining) {
+		return 0
+	}
+	if fromGolds {
+		for _, gold := range remaining[splitIndex:] {
+			if gold == 0 {
+				return 0
+			}
+		}
+	}
+
+	predicted := lastSplit
+	if segmentElapsed > remaining[splitIndex] {
+		predicted += segmentElapsed
+	} else {
+		predicted += remaining[splitIndex]
+	}
+	for _, segment := range remaining[splitIndex+1:] {
+		predicted += segment
+	}
+	return predicted
+}
+
 // GetSplitName returns the split name at index.
 func (d *Data) GetSplitName(index int) string {
 	if index >= len(d.SplitNames) {
@@ -56,6 +94,24 @@ func (d *Data) GetSplitName(index int) string {
 	return d.SplitNames[index].Name
 }
 
+// GetNotes returns the notes of the split at index.
+func (d *Data) GetNotes(index int) string {
+	if index >= len(d.SplitNames) {
+		return ""
+	}
+	return d.SplitNames[index].Notes
+}
+
+// HasNotes returns whether any split in the route has notes.
+func (d *Data) HasNotes() bool {
+	for _, sn := range d.SplitNames {
+		if sn.Notes != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // GetComparisonSplit returns the total time the comparison run had at index.
 func (d *Data) GetComparisonSplit(index int) time.Duration {
 	if index >= len(d.ComparisonSplits) {
@@ -88,29 +144,171 @@ func (d *Data) GetTimeSave(index int) time.Duration {
 	return d.TimeSaves[index]
 }
 
+// Range returns the data of the splits from index from to index to, inclusive.
+// Comparison splits are counted from the start of the range and the best time is the range's comparison.
+// It is for timing part of a route, such as in practice.
+func (d *Data) Range(from, to int) *Data {
+	r := &Data{
+		RouteName:          d.RouteName,
+		RouteID:            d.RouteID,
+		Category:           d.Category,
+		BestRunID:          d.BestRunID,
+		ComparisonRun:      d.ComparisonRun,
+		TotalRuns:          d.TotalRuns,
+		Tag:                d.Tag,
+		SplitNames:         d.SplitNames[from : to+1],
+		ComparisonSplits:   []time.Duration{},
+		ComparisonSegments: []time.Duration{},
+		Golds:              []time.Duration{},
+		TimeSaves:          []time.Duration{},
+		Length:             to - from + 1,
+	}
+
+	// Golds and the comparison are either set for every split or none of them.
+	if to >= len(d.Golds) {
+		return r
+	}
+
+	var split, sumOfGold time.Duration
+	for i := from; i <= to; i++ {
+		split += d.ComparisonSegments[i]
+		sumOfGold += d.Golds[i]
+
+		r.ComparisonSplits = append(r.ComparisonSplits, split)
+		r.ComparisonSegments = append(r.ComparisonSegments, d.ComparisonSegments[i])
+		r.Golds = append(r.Golds, d.Golds[i])
+		r.TimeSaves = append(r.TimeSaves, d.TimeSaves[i])
+	}
+	r.RouteBestTime = &split
+	r.SumOfGold = &sumOfGold
+	return r
+}
+
+// FromRuns returns a copy of d with the best time, comparison and golds of only runs.
+// durations must be the valid segments of runs. Practice segments aren't counted.
+// It is for comparing against a subset of runs, such as the runs with a tag.
+func (d *Data) FromRuns(runs []Run, durations []split.Duration) *Data {
+	r := &Data{
+		RouteName:          d.RouteName,
+		RouteID:            d.RouteID,
+		Category:           d.Category,
+		TotalRuns:          int64(len(runs)),
+		SplitNames:         d.SplitNames,
+		ComparisonSplits:   []time.Duration{},
+		ComparisonSegments: []time.Duration{},
+		Golds:              []time.Duration{},
+		TimeSaves:          []time.Duration{},
+		Length:             d.Length,
+		Tag:                d.Tag,
+	}
+
+	best := BestRun(runs)
+	if best == nil {
+		return r
+	}
+
+	golds := map[int64]time.Duration{}
+	bestSegments := map[int64]time.Duration{}
+	for _, duration := range durations {
+		if gold, ok := golds[duration.NameID]; !ok || duration.Duration < gold {
+			golds[duration.NameID] = duration.Duration
+		}
+		if duration.RunID == best.ID {
+			bestSegments[duration.NameID] = duration.Duration
+		}
+	}
+
+	for _, sn := range d.SplitNames {
+		r.AddComparison(bestSegments[sn.ID], golds[sn.ID])
+	}
+
+	bestTime := best.Duration
+	r.RouteBestTime = &bestTime
+	r.BestRunID = best.ID
+	return r
+}
+
+// CompareTo returns a copy of d that uses run as the comparison instead of the best run.
+// segments must be the run's segments. The best time and golds stay the same.
+// It is for racing a specific run, such as a run from last week or an imported run.
+func (d *Data) CompareTo(run Run, segments []split.Duration) (*Data, error) {
+	if run.RouteID != d.RouteID {
+		return nil, fmt.Errorf("run %d isn't in %s", run.ID, d.RouteName)
+	}
+	if !run.IsFull() {
+		return nil, fmt.Errorf("run %d is a partial run and can't be compared to", run.ID)
+	}
+
+	runSegments := map[int64]time.Duration{}
+	for _, s := range segments {
+		runSegments[s.NameID] = s.Duration
+	}
+
+	r := *d
+	r.ComparisonRun = &run
+	r.ComparisonSplits = []time.Duration{}
+	r.ComparisonSegments = []time.Duration{}
+	r.TimeSaves = []time.Duration{}
+
+	var split time.Duration
+	for i, sn := range d.SplitNames {
+		segment, ok := runSegments[sn.ID]
+		if !ok {
+			return nil, fmt.Errorf("run %d doesn't have a segment for %s", run.ID, sn.Name)
+		}
+		split += segment
+
+		r.ComparisonSplits = append(r.ComparisonSplits, split)
+		r.ComparisonSegments = append(r.ComparisonSegments, segment)
+		if i < len(d.Golds) {
+			r.TimeSaves = append(r.TimeSaves, segment-d.Golds[i])
+		}
+	}
+	return &r, nil
+}
+
+// AddComparison appends the comparison segment and gold of the next split, and adds the gold to the sum of gold.
+// The time save is zero when the split has no gold.
+func (d *Data) AddComparison(segment, gold time.Duration) {
+	if d.SumOfGold == nil {
+		d.SumOfGold = new(time.Duration)
+	}
+	*d.SumOfGold += gold
+
+	var split time.Duration
+	if len(d.ComparisonSplits) > 0 {
+		split = d.ComparisonSplits[len(d.ComparisonSplits)-1]
+	}
+	d.ComparisonSplits = append(d.ComparisonSplits, split+segment)
+	d.ComparisonSegments = append(d.ComparisonSegments, segment)
+	d.Golds = append(d.Golds, gold)
+	if gold == 0 {
+		d.TimeSaves = append(d.TimeSaves, 0)
+	} else {
+		d.TimeSaves = append(d.TimeSaves, segment-gold)
+	}
+}
+
 // GetData gets a routes data by its primary key.
-// Returns nil if the route isn't found.
-func GetData(routeID int64) (*Data, error) {
+// Returns ErrNotFound if the route isn't found.
+//
+// Golds and the best run come from the gold and route_best tables, which are kept up to date by SaveBests.
+// That keeps loading a route proportional to its amount of splits instead of its amount of runs.
+func GetData(q db.Querier, routeID int64) (*Data, error) {
 	var (
 		routeBestTime    *int64
+		bestRunID        *int64
 		categoryBestTime *int64
+		gameID           *int64
+		gameName         *string
 		currBest         *int64
 		currGold         *int64
-		sumOfGold        *int64
-		zero             int64
-		totalRuns        int64
 	)
 
 	if routeID == 0 {
 		return nil, errors.New("id is required")
 	}
 
-	rows, err := dataQuery(routeID)
-	if err != nil {
-		return nil, fmt.Errorf("failed route data query: %w", err)
-	}
-	defer rows.Close()
-
 	d := new(Data)
 	d.Category = &category.Name{}
 	d.SplitNames = []split.Name{}
@@ -119,123 +317,121 @@ func GetData(routeID int64) (*Data, error) {
 	d.Golds = []time.Duration{}
 	d.TimeSaves = []time.Duration{}
 
+	err := q.QueryRow(`
+SELECT
+  r.id,
+  r.name,
+  best.nanoseconds AS route_best,
+  best.run_id AS best_run_id,
+  (SELECT COUNT(*) FROM run WHERE run.route_id = r.id) AS total_runs,
+  c.id,
+  c.name,
+  c.game_id,
+  g.name,
+  (
+    SELECT MIN(category_best.nanoseconds)
+    FROM route_best AS category_best
+    JOIN route ON route.id = category_best.route_id
+    WHERE route.category_id = c.id
+  ) AS category_best
+FROM
+  route AS r
+  JOIN category AS c ON c.id = r.category_id
+  LEFT JOIN game AS g ON g.id = c.game_id
+  LEFT JOIN route_best AS best ON best.route_id = r.id
+WHERE
+  r.id = ?`, routeID).Scan(
+		&d.RouteID,
+		&d.RouteName,
+		&routeBestTime,
+		&bestRunID,
+		&d.TotalRuns,
+		&d.Category.ID,
+		&d.Category.Name,
+		&gameID,
+		&gameName,
+		&categoryBestTime,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed route data query: %w", err)
+	}
+	if gameID != nil {
+		d.Category.GameID = *gameID
+		d.Category.Game = *gameName
+	}
+
+	d.Category.Variables, err = category.GetVariables(q, d.Category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+SELECT
+  sn.id,
+  sn.name,
+  sn.notes,
+  gold.nanoseconds,
+  s.nanoseconds
+FROM
+  split_name AS sn
+  LEFT JOIN gold ON gold.split_name_id = sn.id
+  LEFT JOIN route_best AS best ON best.route_id = sn.route_id
+  LEFT JOIN split AS s ON s.run_id = best.run_id AND s.split_name_id = sn.id
+WHERE
+  sn.route_id = ?
+ORDER BY
+  sn.position`, routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed route splits query: %w", err)
+	}
+	defer rows.Close()
+
 	for rows.Next() {
 		sn := split.Name{}
 
 		if err := rows.Scan(
 			&sn.ID,
 			&sn.Name,
+			&sn.Notes,
 			&currGold,
 			&currBest,
-			&d.RouteID,
-			&d.RouteName,
-			&routeBestTime,
-			&totalRuns,
-			&d.Category.ID,
-			&d.Category.Name,
-			&categoryBestTime,
 		); err != nil {
 			return nil, err
 		}
 
 		d.SplitNames = append(d.SplitNames, sn)
-
-		// When the route has a completed run these should be non nil.
-		if currBest != nil && currGold != nil {
-			if sumOfGold == nil {
-				sumOfGold = &zero
-			}
-			*sumOfGold += *currGold
-
-			curBestDur := time.Duration(*currBest * 1e6)
-			if len(d.ComparisonSplits) == 0 {
-				d.ComparisonSplits = append(d.ComparisonSplits, curBestDur)
-			} else {
-				d.ComparisonSplits = append(d.ComparisonSplits, d.ComparisonSplits[len(d.ComparisonSplits)-1]+curBestDur)
-			}
-			d.ComparisonSegments = append(d.ComparisonSegments, time.Duration(*currBest*1e6))
-			d.Golds = append(d.Golds, time.Duration(*currGold*1e6))
-			d.TimeSaves = append(d.TimeSaves, time.Duration((*currBest-*currGold)*1e6))
+		if bestRunID == nil {
+			continue
 		}
 
+		// Every split gets a row so that the slices stay indexed by split.
+		// A split whose segments are all invalid has no gold, and is zero.
+		var best, gold time.Duration
+		if currBest != nil {
+			best = db.ToDuration(*currBest)
+		}
+		if currGold != nil {
+			gold = db.ToDuration(*currGold)
+		}
+		d.AddComparison(best, gold)
 	}
-
-	if sumOfGold != nil {
-		dur := time.Duration(*sumOfGold * 1e6)
-		d.SumOfGold = &dur
-	}
-	if routeBestTime != nil {
-		dur := time.Duration(*routeBestTime * 1e6)
-		d.RouteBestTime = &dur
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if categoryBestTime != nil {
-		dur := time.Duration(*categoryBestTime * 1e6)
-		d.Category.Best = &dur
+	d.RouteBestTime = db.ToNullDuration(routeBestTime)
+	d.Category.Best = db.ToNullDuration(categoryBestTime)
+	if bestRunID != nil {
+		d.BestRunID = *bestRunID
 	}
 
 	if len(d.SplitNames) == 0 {
-		return nil, errors.New("route not found")
+		return nil, ErrNotFound
 	}
 
 	d.Length = len(d.SplitNames)
 	return d, nil
 }
-
-func dataQuery(routeID int64) (*sql.Rows, error) {
-	query := fmt.Sprintf(`
-SELECT
-  sn.id AS split_name_id,
-  sn.name AS split_name,
-  MIN(golds.milliseconds) AS gold_split,
-  route_best.milliseconds AS route_best_split,
-  r.id AS route_id,
-  r.name AS route_name,
-  MIN(run.milliseconds) AS route_best,
-  COUNT(DISTINCT run.id) AS total_runs,
-  c.id AS category_id,
-  c.name AS category_name,
-  category_best.milliseconds AS category_best
-FROM
-  route AS r
-  JOIN category AS c ON c.id = r.category_id
-  JOIN split_name AS sn ON sn.route_id = r.id
-  LEFT JOIN split AS golds ON golds.split_name_id = sn.id
-  LEFT JOIN run ON run.route_id = r.id
-  LEFT JOIN (
-    SELECT
-      r.id AS route_id,
-      sn.id AS split_name_id,
-      s.milliseconds
-    FROM
-      category AS c
-      JOIN route AS r ON r.category_id = c.id
-      JOIN run ON run.route_id = r.id
-      JOIN split_name AS sn ON sn.route_id = r.id
-      JOIN split AS s ON s.split_name_id = sn.id
-      AND s.run_id = run.id
-    GROUP BY
-      r.id,
-      sn.id
-    HAVING
-      MIN(run.milliseconds) = run.milliseconds
-  ) AS route_best ON route_best.split_name_id = sn.id
-  LEFT JOIN (
-    SELECT
-      MIN(run.milliseconds) AS milliseconds,
-      c.id
-    FROM
-      category AS c
-      JOIN route AS r ON r.category_id = c.id
-      JOIN run ON run.route_id = r.id
-    GROUP BY
-      c.id
-  ) AS category_best ON category_best.id = c.id
-  WHERE
-    r.id = ?
-GROUP BY
-  sn.id
-ORDER BY r.id, sn.position;
-`)
-	return db.Connection.Query(query, routeID)
-}
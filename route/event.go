@@ -0,0 +1,86 @@
+package route
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/knoebber/gsplits/db"
+)
+
+// Event is something that happened on the timer at a wall clock time.
+type Event struct {
+	ID         int64
+	RouteID    int64     `validate:"required"`
+	RunID      int64     // Zero for events that aren't part of a saved run, such as resets.
+	Type       string    `validate:"required"`
+	SplitIndex int       // The split that the event happened at.
+	Time       time.Time `validate:"required"`
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s event", e.Type)
+}
+
+// Save inserts the event into the event table.
+func (e *Event) Save(tx *sql.Tx) (sql.Result, error) {
+	var runID interface{}
+
+	if err := db.Validate(e); err != nil {
+		return nil, err
+	}
+	if e.RunID != 0 {
+		runID = e.RunID
+	}
+
+	return tx.Exec(
+		"INSERT INTO event(route_id, run_id, type, split_index, happened_at) VALUES (?, ?, ?, ?, ?)",
+		e.RouteID,
+		runID,
+		e.Type,
+		e.SplitIndex,
+		e.Time.UTC(),
+	)
+}
+
+// GetEvents returns the events of a run in the order they happened.
+func GetEvents(q db.Querier, runID int64) ([]Event, error) {
+	return queryEvents(q, "run_id", runID)
+}
+
+// GetRouteEvents returns every event of a route in the order they happened.
+// It includes events that aren't part of a saved run, such as resets.
+func GetRouteEvents(q db.Querier, routeID int64) ([]Event, error) {
+	return queryEvents(q, "route_id", routeID)
+}
+
+// Returns the events where column equals id.
+func queryEvents(q db.Querier, column string, id int64) ([]Event, error) {
+	var result []Event
+
+	rows, err := q.Query(`
+SELECT id, route_id, ifnull(run_id, 0), type, split_index, happened_at
+FROM event
+WHERE `+column+` = ?
+ORDER BY happened_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := Event{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.RouteID,
+			&e.RunID,
+			&e.Type,
+			&e.SplitIndex,
+			&e.Time,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
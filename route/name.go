@@ -27,20 +27,9 @@ func (r *Name) Save(tx *sql.Tx) (sql.Result, error) {
 	return tx.Exec("INSERT INTO route(name, category_id) VALUES(?,?)", r.Name, r.CategoryID)
 }
 
-// SearchNames finds route names that contain q.
-func SearchNames(q string) ([]Name, error) {
-	search := "%" + q + "%"
-	rows, err := db.Connection.Query(`SELECT id, name FROM route WHERE name like ? ORDER BY id`, search)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search routes: %w", err)
-	}
-
-	return getNames(rows)
-}
-
 // GetByCategory returns a list routes names that are in the category.
-func GetByCategory(categoryID int64) ([]Name, error) {
-	rows, err := db.Connection.Query(`SELECT id, name FROM route WHERE category_id = ? ORDER BY id`, categoryID)
+func GetByCategory(q db.Querier, categoryID int64) ([]Name, error) {
+	rows, err := q.Query(`SELECT id, name FROM route WHERE category_id = ? ORDER BY id`, categoryID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get routes: %w", err)
 	}
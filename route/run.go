@@ -2,29 +2,215 @@ package route
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
 )
 
 // Run is a single run in a route.
 type Run struct {
-	ID        int64
-	RouteID   int64         `validate:"required"`
-	Duration  time.Duration `validate:"required"`
-	CreatedAt time.Time     `validate:"required"`
+	ID         int64
+	RouteID    int64          `validate:"required"`
+	Duration   time.Duration  `validate:"required"`
+	StartIndex int            // The split that a partial run started from. Zero for full runs.
+	Invalid    bool           // Whether the run is left out of bests and statistics.
+	Unfinished bool           // Whether the run was saved before its last split, such as a recovered run. Its segments still count.
+	Comment    string         // A note from the runner about the run.
+	Tags       []string       // Labels for filtering runs, such as "emulator". Saved in the order of NormalizeTags.
+	Variables  game.Variables // Properties of the run that differ from its category, such as the platform.
+	CreatedAt  time.Time      `validate:"required"`
+	StartedAt  time.Time      // When the timer was started. Zero for runs saved before it was recorded.
+	Events     []Event        // What happened on the timer during the run.
 }
 
 func (r Run) String() string {
 	return "run"
 }
 
+// IsFull returns whether the run has every segment of the route.
+func (r Run) IsFull() bool {
+	return r.StartIndex == 0 && !r.Unfinished
+}
+
+// BestRun returns the fastest full valid run in runs, or nil when there isn't one.
+// Ties go to the earliest run, like SaveBests.
+func BestRun(runs []Run) *Run {
+	var best *Run
+	for i, run := range runs {
+		if !run.IsFull() || run.Invalid {
+			continue
+		}
+		if best == nil || run.Duration < best.Duration || run.Duration == best.Duration && run.ID < best.ID {
+			best = &runs[i]
+		}
+	}
+	return best
+}
+
 // Save inserts the run into the runs table.
 func (r *Run) Save(tx *sql.Tx) (sql.Result, error) {
-	r.CreatedAt = time.Now()
+	r.CreatedAt = time.Now().UTC()
 	if err := db.Validate(r); err != nil {
 		return nil, err
 	}
-	ms := r.Duration.Nanoseconds() / 1e6
-	return tx.Exec("INSERT INTO run(route_id, milliseconds) VALUES(?, ?)", r.RouteID, ms)
+	return tx.Exec(
+		"INSERT INTO run(route_id, nanoseconds, start_index, started_at, comment, unfinished) VALUES(?, ?, ?, ?, ?, ?)",
+		r.RouteID,
+		db.FromDuration(r.Duration),
+		r.StartIndex,
+		db.NullTime(r.StartedAt),
+		r.Comment,
+		r.Unfinished,
+	)
+}
+
+const runColumns = "id, route_id, nanoseconds, start_index, invalid, unfinished, comment, created_at, started_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRun(row scanner) (*Run, error) {
+	var (
+		nanoseconds int64
+		startedAt   *time.Time
+	)
+
+	r := new(Run)
+	if err := row.Scan(&r.ID, &r.RouteID, &nanoseconds, &r.StartIndex, &r.Invalid, &r.Unfinished, &r.Comment, &r.CreatedAt, &startedAt); err != nil {
+		return nil, err
+	}
+
+	r.Duration = db.ToDuration(nanoseconds)
+	if startedAt != nil {
+		r.StartedAt = *startedAt
+	}
+	return r, nil
+}
+
+// GetRun returns the run with runID.
+// Returns nil when the run isn't found.
+func GetRun(q db.Querier, runID int64) (*Run, error) {
+	r, err := scanRun(q.QueryRow("SELECT "+runColumns+" FROM run WHERE id = ?", runID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run %d: %w", runID, err)
+	}
+
+	tags, err := queryTags(q, "run_id = ?", runID)
+	if err != nil {
+		return nil, err
+	}
+	r.Tags = tags[r.ID]
+
+	variables, err := queryVariables(q, "run_id = ?", runID)
+	if err != nil {
+		return nil, err
+	}
+	r.Variables = variables[r.ID]
+	return r, nil
+}
+
+// GetRuns returns the runs of a route in the order they were saved.
+func GetRuns(q db.Querier, routeID int64) ([]Run, error) {
+	rows, err := q.Query("SELECT "+runColumns+" FROM run WHERE route_id = ? ORDER BY id", routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runs: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Run{}
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tags, err := queryTags(q, "run_id IN (SELECT id FROM run WHERE route_id = ?)", routeID)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := queryVariables(q, "run_id IN (SELECT id FROM run WHERE route_id = ?)", routeID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Tags = tags[result[i].ID]
+		result[i].Variables = variables[result[i].ID]
+	}
+	return result, nil
+}
+
+// SetInvalid sets whether the run with runID is invalid.
+func SetInvalid(q db.Querier, runID int64, invalid bool) error {
+	res, err := q.Exec("UPDATE run SET invalid = ? WHERE id = ?", invalid, runID)
+	if err != nil {
+		return fmt.Errorf("failed to update run %d: %w", runID, err)
+	}
+	return db.CheckUpdated(res, fmt.Sprintf("run %d", runID))
+}
+
+// DeleteRun deletes the run with runID.
+// Its segments, events, tags and variables are deleted with it.
+// The golds and best run aren't updated, so callers need to recompute them.
+func DeleteRun(q db.Querier, runID int64) error {
+	res, err := q.Exec("DELETE FROM run WHERE id = ?", runID)
+	if err != nil {
+		return fmt.Errorf("failed to delete run %d: %w", runID, err)
+	}
+	return db.CheckUpdated(res, fmt.Sprintf("run %d", runID))
+}
+
+// Annotate sets the comment of the run with runID and replaces its tags.
+func Annotate(tx *sql.Tx, runID int64, comment string, tags []string) error {
+	res, err := tx.Exec("UPDATE run SET comment = ? WHERE id = ?", comment, runID)
+	if err != nil {
+		return fmt.Errorf("failed to update run %d: %w", runID, err)
+	}
+	if err := db.CheckUpdated(res, fmt.Sprintf("run %d", runID)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM run_tag WHERE run_id = ?", runID); err != nil {
+		return fmt.Errorf("failed to delete tags of run %d: %w", runID, err)
+	}
+	return SaveTags(tx, runID, tags)
+}
+
+// SaveVariables replaces the variables of the run with runID.
+func SaveVariables(tx *sql.Tx, runID int64, v game.Variables) error {
+	if _, err := tx.Exec("DELETE FROM run_variable WHERE run_id = ?", runID); err != nil {
+		return fmt.Errorf("failed to delete run variables: %w", err)
+	}
+	for name, value := range v {
+		if _, err := tx.Exec(
+			"INSERT INTO run_variable(run_id, name, value) VALUES (?, ?, ?)",
+			runID,
+			name,
+			value,
+		); err != nil {
+			return fmt.Errorf("failed to save run variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Returns the variables of the runs that match where, which has one parameter for id.
+// The result is keyed by run ID.
+func queryVariables(q db.Querier, where string, id int64) (map[int64]game.Variables, error) {
+	rows, err := q.Query("SELECT run_id, name, value FROM run_variable WHERE "+where, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run variables: %w", err)
+	}
+	return game.ScanVariables(rows)
 }
@@ -0,0 +1,289 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/knoebber/gsplits/db"
+)
+
+const (
+	// Separates a category qualifier from the route in a search.
+	// Example: mario 64/16 star
+	qualifierSeparator = "/"
+
+	// The most points a route can get for being recently run.
+	maxRecencyScore = 10
+
+	// The most suggestions returned when nothing matches.
+	maxSuggestions = 5
+)
+
+// Match is a route that matched a search.
+type Match struct {
+	Name
+	GameName     string // Empty when the route's category isn't in a game.
+	CategoryName string
+	LastRun      *time.Time // When the route was last run. Nil when it has no runs.
+	Score        int
+}
+
+func (m Match) String() string {
+	return fmt.Sprintf("%s%s%s", m.CategoryName, qualifierSeparator, m.Name.Name)
+}
+
+// Search finds the routes that fuzzy match q.
+// Results are ranked by how well they match and how recently they were run.
+//
+// q may be qualified with a category, "category/route", or a game and a category, "game/category/route".
+// When no route names match q, routes are matched by their category name instead, and then by their game name.
+func Search(routes []Match, q string) []Match {
+	var gameQuery, categoryQuery string
+
+	q = strings.TrimSpace(q)
+	parts := strings.SplitN(q, qualifierSeparator, 3)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	routeQuery := parts[len(parts)-1]
+	if len(parts) > 1 {
+		categoryQuery = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		gameQuery = parts[0]
+	}
+
+	result := rank(routes, func(m *Match) int {
+		gameScore, categoryScore, routeScore := 0, 0, 0
+		if gameQuery != "" {
+			if gameScore = fuzzyScore(gameQuery, m.GameName); gameScore < 0 {
+				return -1
+			}
+		}
+		if categoryQuery != "" {
+			if categoryScore = fuzzyScore(categoryQuery, m.CategoryName); categoryScore < 0 {
+				return -1
+			}
+		}
+		if routeQuery != "" {
+			if routeScore = fuzzyScore(routeQuery, m.Name.Name); routeScore < 0 {
+				return -1
+			}
+		}
+		return gameScore + categoryScore + routeScore
+	})
+
+	if len(result) == 0 && categoryQuery == "" {
+		result = rank(routes, func(m *Match) int {
+			return fuzzyScore(q, m.CategoryName)
+		})
+	}
+	if len(result) == 0 && categoryQuery == "" {
+		result = rank(routes, func(m *Match) int {
+			return fuzzyScore(q, m.GameName)
+		})
+	}
+	return result
+}
+
+// Suggest returns the routes with names that are close to q.
+// It is meant for when Search has no results, usually because of a typo.
+func Suggest(routes []Match, q string) []Match {
+	q = strings.ToLower(strings.TrimSpace(q))
+	maxDistance := len(q)/3 + 1
+
+	result := rank(routes, func(m *Match) int {
+		distance := editDistance(q, strings.ToLower(m.Name.Name))
+		if qualified := editDistance(q, strings.ToLower(m.String())); qualified < distance {
+			distance = qualified
+		}
+		if distance > maxDistance {
+			return -1
+		}
+		return maxDistance - distance
+	})
+
+	if len(result) > maxSuggestions {
+		result = result[:maxSuggestions]
+	}
+	return result
+}
+
+// Scores every route and returns the ones with a non negative score.
+// The result is ordered by score plus recency.
+func rank(routes []Match, score func(*Match) int) []Match {
+	now := time.Now()
+	result := []Match{}
+
+	for _, m := range routes {
+		s := score(&m)
+		if s < 0 {
+			continue
+		}
+		m.Score = s + recencyScore(m.LastRun, now)
+		result = append(result, m)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Routes run in the last week get the max score, losing a point for every week since.
+func recencyScore(lastRun *time.Time, now time.Time) int {
+	if lastRun == nil {
+		return 0
+	}
+	weeks := int(now.Sub(*lastRun) / (7 * 24 * time.Hour))
+	if weeks >= maxRecencyScore {
+		return 0
+	}
+	return maxRecencyScore - weeks
+}
+
+// Returns how well q fuzzy matches s, or -1 when it doesn't.
+// Every rune in q must appear in s in order.
+// Consecutive runes and runes at the start of words score higher.
+func fuzzyScore(q, s string) int {
+	query := []rune(strings.ToLower(q))
+	target := []rune(strings.ToLower(s))
+
+	if len(query) == 0 {
+		return 0
+	}
+
+	score := 0
+	qi := 0
+	lastMatch := -1
+
+	for ti, r := range target {
+		if qi == len(query) {
+			break
+		}
+		if r != query[qi] {
+			continue
+		}
+
+		score++
+		if lastMatch >= 0 && lastMatch == ti-1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(target[ti-1]) && !unicode.IsDigit(target[ti-1]) {
+			score += 2
+		}
+
+		lastMatch = ti
+		qi++
+	}
+
+	if qi < len(query) {
+		return -1
+	}
+
+	// Prefer exact and substring matches over scattered ones.
+	lq, ls := string(query), string(target)
+	if lq == ls {
+		score += 20
+	} else if strings.HasPrefix(ls, lq) {
+		score += 10
+	} else if strings.Contains(ls, lq) {
+		score += 5
+	}
+	return score
+}
+
+// Returns the levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// All returns every route with its category name and when it was last run.
+// The result can be passed to Search and Suggest.
+func All(q db.Querier) ([]Match, error) {
+	var (
+		lastRun  *int64
+		gameName *string
+	)
+
+	rows, err := q.Query(`
+SELECT
+  r.id,
+  r.name,
+  r.category_id,
+  c.name,
+  g.name,
+  CAST(strftime('%s', MAX(run.created_at)) AS INTEGER) AS last_run
+FROM
+  route AS r
+  JOIN category AS c ON c.id = r.category_id
+  LEFT JOIN game AS g ON g.id = c.game_id
+  LEFT JOIN run ON run.route_id = r.id
+GROUP BY
+  r.id
+ORDER BY
+  r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Match{}
+	for rows.Next() {
+		m := Match{}
+		if err := rows.Scan(
+			&m.ID,
+			&m.Name.Name,
+			&m.CategoryID,
+			&m.CategoryName,
+			&gameName,
+			&lastRun,
+		); err != nil {
+			return nil, err
+		}
+		if gameName != nil {
+			m.GameName = *gameName
+		}
+		if lastRun != nil {
+			t := time.Unix(*lastRun, 0)
+			m.LastRun = &t
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,107 @@
+package route
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/split"
+)
+
+// ParseTags returns the tags in s, which are separated by commas or spaces.
+func ParseTags(s string) []string {
+	return NormalizeTags(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}))
+}
+
+// NormalizeTags returns tags in lower case and sorted, without blanks or duplicates.
+// Returns nil when there are no tags.
+func NormalizeTags(tags []string) []string {
+	var result []string
+
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// HasTag returns whether the run is tagged with tag.
+// Every run has the empty tag, so that an empty tag doesn't filter anything.
+func (r Run) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return true
+	}
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByTag returns the runs that are tagged with tag and the durations of those runs.
+// Everything is returned when tag is empty.
+func FilterByTag(runs []Run, durations []split.Duration, tag string) ([]Run, []split.Duration) {
+	ids := map[int64]bool{}
+	filteredRuns := []Run{}
+	for _, run := range runs {
+		if run.HasTag(tag) {
+			ids[run.ID] = true
+			filteredRuns = append(filteredRuns, run)
+		}
+	}
+
+	filteredDurations := []split.Duration{}
+	for _, d := range durations {
+		if ids[d.RunID] {
+			filteredDurations = append(filteredDurations, d)
+		}
+	}
+	return filteredRuns, filteredDurations
+}
+
+// SaveTags inserts the tags of the run with runID.
+// The tags are normalized with NormalizeTags.
+func SaveTags(tx *sql.Tx, runID int64, tags []string) error {
+	for _, tag := range NormalizeTags(tags) {
+		if _, err := tx.Exec("INSERT INTO run_tag(run_id, tag) VALUES (?, ?)", runID, tag); err != nil {
+			return fmt.Errorf("failed to save tag %q: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Returns the tags of the runs that match where, which has one parameter for id.
+// The result is keyed by run ID and each run's tags are sorted.
+func queryTags(q db.Querier, where string, id int64) (map[int64][]string, error) {
+	rows, err := q.Query("SELECT run_id, tag FROM run_tag WHERE "+where+" ORDER BY run_id, tag", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	result := map[int64][]string{}
+	for rows.Next() {
+		var (
+			runID int64
+			tag   string
+		)
+		if err := rows.Scan(&runID, &tag); err != nil {
+			return nil, err
+		}
+		result[runID] = append(result[runID], tag)
+	}
+	return result, rows.Err()
+}
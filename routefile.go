@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
+	"gopkg.in/yaml.v2"
+)
+
+// routeFile is a route that is declared in a file.
+//
+// YAML files look like:
+//
+//	game: Super Mario 64
+//	category: Mario 64 16 star
+//	variables:
+//	  platform: N64
+//	route: Standard
+//	splits:
+//	  - Bob-omb Battlefield
+//	  - Whomp's Fortress
+//
+// Any other file is read as plain text with one split name per line.
+// Blank lines and lines starting with # are ignored.
+//
+// The game and variables are only used when the category is created.
+type routeFile struct {
+	Game      string            `yaml:"game"`
+	Category  string            `yaml:"category" validate:"required"`
+	Variables map[string]string `yaml:"variables"`
+	Route     string            `yaml:"route" validate:"required"`
+	Splits    []string          `yaml:"splits" validate:"required,min=1,dive,required"`
+}
+
+func readRouteFile(path string) (*routeFile, error) {
+	rf := new(routeFile)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(content, rf); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			rf.Splits = append(rf.Splits, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	for i := range rf.Splits {
+		rf.Splits[i] = strings.TrimSpace(rf.Splits[i])
+	}
+	return rf, nil
+}
+
+func (rf *routeFile) print() {
+	if rf.Game != "" {
+		fmt.Printf("Game: %s\n", rf.Game)
+	}
+	fmt.Printf("Category: %s\n", rf.Category)
+	if len(rf.Variables) > 0 {
+		fmt.Printf("Variables: %s\n", rf.variables())
+	}
+	fmt.Printf("Route: %s\n", rf.Route)
+	fmt.Println(divider)
+	for i, name := range rf.Splits {
+		fmt.Printf("%d.) %s\n", i+1, name)
+	}
+}
+
+// Returns the variables of the file with lower case names.
+func (rf *routeFile) variables() game.Variables {
+	if len(rf.Variables) == 0 {
+		return nil
+	}
+
+	v := game.Variables{}
+	for name, value := range rf.Variables {
+		v[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return v
+}
+
+// Creates a route from the file passed to --from.
+func newRouteCommand(args []string) error {
+	var routeID int64
+
+	flags := flag.NewFlagSet("new-route", flag.ExitOnError)
+	from := flags.String("from", "", "YAML or plain text file that declares the route")
+	gameName := flags.String("game", "", "game name, overrides the file")
+	categoryName := flags.String("category", "", "category name, overrides the file")
+	routeName := flags.String("name", "", "route name, overrides the file")
+	dryRun := flags.Bool("dry-run", false, "validate and print the route without saving it")
+	flags.Parse(args)
+
+	if *from == "" {
+		return errors.New("new-route: --from is required")
+	}
+
+	rf, err := readRouteFile(*from)
+	if err != nil {
+		return err
+	}
+	if *gameName != "" {
+		rf.Game = *gameName
+	}
+	if *categoryName != "" {
+		rf.Category = *categoryName
+	}
+	if *routeName != "" {
+		rf.Route = *routeName
+	}
+	if err := db.Validate(rf); err != nil {
+		return fmt.Errorf("invalid route file %s: %w", *from, err)
+	}
+
+	c, err := storage.Categories().GetByName(rf.Category)
+	if err != nil {
+		return err
+	}
+
+	taken, err := routeNameTaken(rf.Route)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("new-route: a route is already named %#v", rf.Route)
+	}
+
+	rf.print()
+	if c == nil {
+		fmt.Printf("Category %#v will be created\n", rf.Category)
+	} else if rf.Game != "" || len(rf.Variables) > 0 {
+		fmt.Printf("Category %#v already exists, its game and variables are kept\n", rf.Category)
+	}
+	if *dryRun {
+		return nil
+	}
+
+	if c == nil {
+		var gameID int64
+		if rf.Game != "" {
+			gameID, err = getOrSaveGame(rf.Game)
+			if err != nil {
+				return err
+			}
+		}
+		routeID, err = saveRouteWithCategory(&category.Name{
+			Name:      rf.Category,
+			GameID:    gameID,
+			Variables: rf.variables(),
+		}, rf.Route, rf.Splits)
+	} else {
+		routeID, err = saveRoute(c.ID, rf.Route, rf.Splits)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved route %#v (%d) with %d splits\n", rf.Route, routeID, len(rf.Splits))
+	return nil
+}
+
+// Returns whether a route already has name.
+// Route names are unique across categories.
+func routeNameTaken(name string) (bool, error) {
+	routes, err := storage.Routes().All()
+	if err != nil {
+		return false, err
+	}
+	for _, r := range routes {
+		if r.Name.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
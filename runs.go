@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
+	"github.com/rivo/tview"
+)
+
+// Lists the saved runs of a route so that their comments and tags can be edited.
+func runsCommand(args []string) error {
+	flags := flag.NewFlagSet("runs", flag.ExitOnError)
+	tag := flags.String("tag", "", "only list runs with the tag")
+	flags.Parse(args)
+
+	routeName := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if routeName == "" {
+		return errors.New("runs: a route name is required")
+	}
+
+	routeID, err := findRoute(routeName)
+	if err != nil {
+		return err
+	}
+	routeData, err := storage.Routes().GetData(routeID)
+	if err != nil {
+		return err
+	}
+
+	app = tview.NewApplication()
+	if err := showRuns(routeData, *tag); err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// Shows a table of the route's runs with the tag, newest first.
+// Selecting a run edits its comment and tags, and d deletes it.
+func showRuns(routeData *route.Data, tag string) error {
+	runs, _, err := store.GetTaggedRuns(storage, routeData.RouteID, tag)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%s: %s", routeData.Category.Name, routeData.RouteName)
+	if tag != "" {
+		title += fmt.Sprintf(", runs tagged %s", tag)
+	}
+
+	table := newRunsTable(runs)
+	table.SetSelectedFunc(func(row, _ int) {
+		if row == 0 {
+			return
+		}
+		showAnnotateForm(runs[len(runs)-row], func() {
+			if err := showRuns(routeData, tag); err != nil {
+				app.Stop()
+				exit(err)
+			}
+		})
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := table.GetSelection()
+		if event.Rune() != 'd' || row == 0 || len(runs) == 0 {
+			return event
+		}
+		confirmDeleteRun(runs[len(runs)-row], func() {
+			if err := showRuns(routeData, tag); err != nil {
+				app.Stop()
+				exit(err)
+			}
+		})
+		return nil
+	})
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			app.Stop()
+		}
+	})
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).SetFullScreen(true).
+		AddItem(newText(title), 1, 0, false).
+		AddItem(newText("Enter edits a run's comment, tags and variables, d deletes it, Esc quits"), 1, 0, false).
+		AddItem(table, 0, 1, true)
+
+	app.SetRoot(flex, true).SetFocus(table)
+	return nil
+}
+
+// Returns a selectable table of runs, newest first.
+// Row r is runs[len(runs)-r], and row 0 is the header.
+func newRunsTable(runs []route.Run) *tview.Table {
+	table := newTable().SetSelectable(true, false).SetFixed(1, 0)
+	for col, value := range []string{"ID", "Saved", "Time", "Tags", "Variables", "Comment"} {
+		setTableCell(table, 0, col, value, tcell.ColorYellow)
+	}
+
+	for i := range runs {
+		run := runs[len(runs)-1-i]
+
+		runTime := durationStr(run.Duration)
+		if run.StartIndex > 0 {
+			runTime += " (partial)"
+		}
+		if run.Unfinished {
+			runTime += " (unfinished)"
+		}
+		color := tcell.ColorDefault
+		if run.Invalid {
+			runTime += " (invalid)"
+			color = tcell.ColorGray
+		}
+
+		for col, value := range []string{
+			fmt.Sprint(run.ID),
+			run.CreatedAt.Local().Format("Jan 2 2006 15:04"),
+			runTime,
+			strings.Join(run.Tags, ", "),
+			run.Variables.String(),
+			run.Comment,
+		} {
+			setTableCell(table, i+1, col, value, color)
+		}
+	}
+
+	if len(runs) > 0 {
+		table.Select(1, 0)
+	}
+	return table
+}
+
+// Shows the full runs of the route that the timer can be compared against.
+// Picking a run or the best run sets the preview to it, and back is called when nothing is picked.
+func showComparePicker(routeData *route.Data, back func()) error {
+	tagged, _, err := store.GetTaggedRuns(storage, routeData.RouteID, routeData.Tag)
+	if err != nil {
+		return err
+	}
+
+	// Partial and unfinished runs don't have a time for every split.
+	runs := []route.Run{}
+	for _, run := range tagged {
+		if run.IsFull() {
+			runs = append(runs, run)
+		}
+	}
+
+	compare := func(runID int64) {
+		compared, err := store.GetTaggedData(storage, routeData.RouteID, routeData.Tag)
+		if err == nil && runID != 0 {
+			compared, err = store.CompareToRun(storage, compared, runID)
+		}
+		if err == nil {
+			err = setPreview(compared)
+		}
+		if err != nil {
+			app.Stop()
+			exit(err)
+		}
+	}
+
+	table := newRunsTable(runs)
+	table.SetSelectedFunc(func(row, _ int) {
+		if row > 0 {
+			compare(runs[len(runs)-row].ID)
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() != 'b' {
+			return event
+		}
+		compare(0)
+		return nil
+	})
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			back()
+		}
+	})
+
+	title := fmt.Sprintf("%s: %s, compare to a run", routeData.Category.Name, routeData.RouteName)
+	if routeData.Tag != "" {
+		title += fmt.Sprintf(" tagged %s", routeData.Tag)
+	}
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).SetFullScreen(true).
+		AddItem(newText(title), 1, 0, false).
+		AddItem(newText("Enter compares against a run, b against the best run, Esc goes back"), 1, 0, false).
+		AddItem(table, 0, 1, true)
+
+	app.SetRoot(flex, true).SetFocus(table)
+	return nil
+}
+
+// Asks whether to delete run.
+// back is called after the run is deleted or kept.
+func confirmDeleteRun(run route.Run, back func()) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Delete run %d: %s?", run.ID, strings.TrimSpace(durationStr(run.Duration)))).
+		AddButtons([]string{"Delete", "Keep"})
+
+	modal.SetDoneFunc(func(_ int, label string) {
+		if label != "Delete" {
+			back()
+			return
+		}
+		if err := storage.Runs().Delete(run.ID); err != nil {
+			modal.SetText(fmt.Sprintf("Failed to delete run %d: %s", run.ID, err))
+			return
+		}
+		back()
+	})
+
+	app.SetRoot(modal, false).SetFocus(modal)
+}
+
+// Asks for a new comment, tags and variables for run.
+// back is called after the run is saved or the form is canceled.
+func showAnnotateForm(run route.Run, back func()) {
+	var (
+		comment   = run.Comment
+		tags      = strings.Join(run.Tags, ", ")
+		variables = run.Variables.String()
+	)
+
+	form := tview.NewForm().
+		AddInputField("Comment", comment, 40, nil, func(text string) { comment = text }).
+		AddInputField("Tags", tags, 40, nil, func(text string) { tags = text }).
+		AddInputField("Variables", variables, 40, nil, func(text string) { variables = text })
+
+	form.
+		AddButton("Save", func() {
+			v, err := game.ParseVariables(variables)
+			if err != nil {
+				form.SetTitle(err.Error())
+				return
+			}
+			if err := storage.Runs().Annotate(run.ID, strings.TrimSpace(comment), route.ParseTags(tags)); err != nil {
+				form.SetTitle(err.Error())
+				return
+			}
+			if err := storage.Runs().SetVariables(run.ID, v); err != nil {
+				form.SetTitle(err.Error())
+				return
+			}
+			back()
+		}).
+		AddButton("Cancel", back)
+
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Run %d: %s", run.ID, strings.TrimSpace(durationStr(run.Duration))))
+	app.SetRoot(form, true).SetFocus(form)
+}
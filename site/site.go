@@ -0,0 +1,187 @@
+// Package site generates a static website of every category, route and run.
+// The pages only link to each other, so the output can be hosted anywhere or opened offline.
+// Generating from the same data always gives the same files.
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/report"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/store"
+)
+
+type categoryPage struct {
+	category.Name
+	Routes []route.Name
+}
+
+type gamePage struct {
+	Name       string // Empty for the categories without a game.
+	Categories []categoryPage
+}
+
+type indexPage struct {
+	Tag   string // When set, the best times are of the runs with the tag.
+	Games []gamePage
+}
+
+type routePage struct {
+	*report.Report
+	Runs []route.Run
+}
+
+type runPage struct {
+	Data     *route.Data
+	Run      route.Run
+	Segments []segment
+}
+
+type segment struct {
+	Name      string
+	Duration  time.Duration
+	Split     time.Duration
+	PlusMinus time.Duration // The difference from the route's comparison split.
+	Gold      bool          // Whether the segment is the route's gold.
+	EndedAt   time.Time
+}
+
+func routeFile(routeID int64) string {
+	return fmt.Sprintf("route-%d.html", routeID)
+}
+
+func runFile(runID int64) string {
+	return fmt.Sprintf("run-%d.html", runID)
+}
+
+// Generate writes the website into dir.
+// dir is created when it doesn't exist.
+// When tag isn't empty the pages only have the runs with the tag, including the best times on the index.
+// Route and run pages that are left in dir from an earlier run of Generate, such as deleted runs, are removed.
+func Generate(s store.Store, dir, tag string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	categories, err := s.Categories().All()
+	if err != nil {
+		return err
+	}
+
+	written := map[string]bool{"index.html": true}
+	index := indexPage{Tag: tag}
+	for _, c := range categories {
+		routes, err := s.Routes().GetByCategory(c.ID)
+		if err != nil {
+			return err
+		}
+		if tag != "" {
+			if c.Best, err = store.GetTaggedCategoryBest(s, c.ID, tag); err != nil {
+				return err
+			}
+		}
+		// Categories are sorted by game, so each game's categories are next to each other.
+		if len(index.Games) == 0 || index.Games[len(index.Games)-1].Name != c.Game {
+			index.Games = append(index.Games, gamePage{Name: c.Game})
+		}
+		g := &index.Games[len(index.Games)-1]
+		g.Categories = append(g.Categories, categoryPage{Name: c, Routes: routes})
+
+		for _, r := range routes {
+			if err := generateRoute(s, dir, r.ID, tag, written); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := removeStale(dir, written); err != nil {
+		return err
+	}
+	return writePage(filepath.Join(dir, "index.html"), "index", index)
+}
+
+// Removes the route and run pages in dir that weren't written, such as the pages of deleted runs.
+func removeStale(dir string, written map[string]bool) error {
+	for _, pattern := range []string{"route-*.html", "run-*.html"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if written[filepath.Base(path)] {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Writes the page of a route and a page for each of its runs, and adds their files to written.
+func generateRoute(s store.Store, dir string, routeID int64, tag string, written map[string]bool) error {
+	r, err := report.Build(s, routeID, tag)
+	if err != nil {
+		return err
+	}
+
+	runs, _, err := store.GetTaggedRuns(s, routeID, tag)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if err := generateRun(s, dir, r.Data, run); err != nil {
+			return err
+		}
+		written[runFile(run.ID)] = true
+	}
+
+	written[routeFile(routeID)] = true
+	return writePage(filepath.Join(dir, routeFile(routeID)), "route", routePage{Report: r, Runs: runs})
+}
+
+func generateRun(s store.Store, dir string, d *route.Data, run route.Run) error {
+	durations, err := s.Runs().GetSegments(run.ID)
+	if err != nil {
+		return err
+	}
+
+	page := runPage{Data: d, Run: run}
+	var split time.Duration
+	for j, duration := range durations {
+		// Partial runs only have the segments from their start index.
+		i := run.StartIndex + j
+		split += duration.Duration
+
+		seg := segment{
+			Name:     d.GetSplitName(i),
+			Duration: duration.Duration,
+			Split:    split,
+			Gold:     duration.Duration == d.GetGold(i),
+			EndedAt:  duration.EndedAt,
+		}
+		if comparison := d.GetComparisonSplit(i); comparison != 0 && run.StartIndex == 0 {
+			seg.PlusMinus = split - comparison
+		}
+		page.Segments = append(page.Segments, seg)
+	}
+
+	return writePage(filepath.Join(dir, runFile(run.ID)), "run", page)
+}
+
+func writePage(path, name string, data interface{}) error {
+	var b bytes.Buffer
+
+	if err := pages.ExecuteTemplate(&b, name, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
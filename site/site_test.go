@@ -0,0 +1,101 @@
+package site
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+	"github.com/knoebber/gsplits/store"
+)
+
+// Returns the contents of every file in dir by name.
+func readSite(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]string{}
+	for _, f := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[f.Name()] = string(content)
+	}
+	return result
+}
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsplits-site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := store.NewMemory()
+	routeID, err := s.Routes().SaveWithCategory(&category.Name{Name: "category"}, &route.Name{Name: "route"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2020, 1, 1, 23, 30, 0, 0, time.UTC)
+	var runIDs []int64
+	for i, segments := range [][]time.Duration{{time.Second, 2 * time.Second}, {time.Second, time.Second}} {
+		startedAt := start.AddDate(0, 0, i)
+		runID, err := s.Runs().Save(
+			&route.Run{RouteID: routeID, Duration: segments[0] + segments[1], StartedAt: startedAt, CreatedAt: startedAt},
+			[]split.Duration{
+				{Duration: segments[0], EndedAt: startedAt.Add(segments[0])},
+				{Duration: segments[1], EndedAt: startedAt.Add(segments[0] + segments[1])},
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runIDs = append(runIDs, runID)
+	}
+
+	if err := Generate(s, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	first := readSite(t, dir)
+	for _, name := range []string{"index.html", routeFile(routeID), runFile(runIDs[0]), runFile(runIDs[1])} {
+		if _, ok := first[name]; !ok {
+			t.Errorf("%s wasn't generated", name)
+		}
+	}
+
+	// The time zone of the machine doesn't change the pages.
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	if err := Generate(s, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if second := readSite(t, dir); !reflect.DeepEqual(first, second) {
+		t.Errorf("generating twice gave different files:\n%v\n%v", first, second)
+	}
+
+	if err := s.Runs().Delete(runIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := Generate(s, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	files := readSite(t, dir)
+	if _, ok := files[runFile(runIDs[0])]; ok {
+		t.Errorf("the page of deleted run %d wasn't removed", runIDs[0])
+	}
+	if len(files) != 3 {
+		t.Errorf("generated %d files after deleting a run, want 3", len(files))
+	}
+}
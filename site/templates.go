@@ -0,0 +1,104 @@
+package site
+
+import (
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/knoebber/gsplits/report"
+)
+
+// Dates are in UTC so that the pages are the same wherever they're generated.
+var funcs = template.FuncMap{
+	"duration":  report.FormatDuration,
+	"date":      formatDate,
+	"routeFile": routeFile,
+	"runFile":   runFile,
+	"join": func(tags []string) string {
+		return strings.Join(tags, ", ")
+	},
+	"signed": func(d time.Duration) string {
+		if d > 0 {
+			return "+" + report.FormatDuration(d)
+		}
+		return report.FormatDuration(d)
+	},
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+var pages = template.Must(template.New("site").Funcs(funcs).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.gold { color: #b8860b; }
+</style>
+</head>
+<body>
+<p><a href="index.html">All categories</a></p>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "index"}}{{template "header" "Personal bests"}}<h1>Personal bests</h1>
+{{with .Tag}}<p>Runs tagged {{.}}</p>
+{{end}}{{range .Games}}<h2>{{with .Name}}{{.}}{{else}}No game{{end}}</h2>
+{{range .Categories}}<h3>{{.Name.Name}}</h3>
+{{with .Variables}}<p>Variables: {{.}}</p>
+{{end}}<p>Best: {{with .Best}}{{duration .}}{{else}}-{{end}}</p>
+<ul>
+{{range .Routes}}<li><a href="{{routeFile .ID}}">{{.Name}}</a></li>
+{{end}}</ul>
+{{end}}{{end}}{{template "footer"}}{{end}}
+
+{{define "route"}}{{template "header" .Data.RouteName}}<h1>{{with .Data.Category.Game}}{{.}} - {{end}}{{.Data.Category.Name}}: {{.Data.RouteName}}</h1>
+{{with .Tag}}<p>Runs tagged {{.}}</p>
+{{end}}<table>
+{{with .Data.Category.Variables}}<tr><td>Variables</td><td>{{.}}</td></tr>
+{{end}}<tr><td>Personal best</td><td>{{with .Data.RouteBestTime}}{{duration .}}{{else}}-{{end}}</td></tr>
+<tr><td>Sum of best</td><td>{{with .Data.SumOfGold}}{{duration .}}{{else}}-{{end}}</td></tr>
+<tr><td>Attempts</td><td>{{.Attempts}}{{if .Tag}} (resets not counted){{end}}</td></tr>
+<tr><td>Completed runs</td><td>{{.Report.Runs}}</td></tr>
+<tr><td>Resets</td><td>{{if .Tag}}not counted{{else}}{{.Resets}}{{end}}</td></tr>
+</table>
+<h2>Splits</h2>
+{{if .Tag}}<p>Practice segments aren't tagged, so they aren't counted.</p>
+{{end}}<table>
+<tr><th>Split</th><th>PB split</th><th>PB segment</th><th>Gold</th><th>Mean</th><th>Median</th><th>Std dev</th><th>Count</th><th>Practice best</th><th>Practice count</th></tr>
+{{range .Splits}}<tr><td>{{.Name}}</td><td>{{duration .PBSplit}}</td><td>{{duration .PBSegment}}</td><td>{{duration .Gold}}</td><td>{{duration .Mean}}</td><td>{{duration .Median}}</td><td>{{duration .StdDev}}</td><td>{{.Count}}</td><td>{{duration .Practice.Best}}</td><td>{{.Practice.Count}}</td></tr>
+{{end}}</table>
+<h2>PB progression</h2>
+<table>
+<tr><th>Date</th><th>Time</th><th>Improvement</th></tr>
+{{range .Progression}}<tr><td><a href="{{runFile .RunID}}">{{date .Date}}</a></td><td>{{duration .Time}}</td><td>{{duration .Improvement}}</td></tr>
+{{end}}</table>
+<h2>Runs</h2>
+<table>
+<tr><th>Saved</th><th>Time</th><th>Tags</th><th>Variables</th><th>Comment</th></tr>
+{{range .Runs}}<tr><td><a href="{{runFile .ID}}">{{date .CreatedAt}}</a></td><td>{{duration .Duration}}</td><td>{{join .Tags}}</td><td>{{.Variables}}</td><td>{{.Comment}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+
+{{define "run"}}{{template "header" .Data.RouteName}}<h1>{{with .Data.Category.Game}}{{.}} - {{end}}{{.Data.Category.Name}}: <a href="{{routeFile .Data.RouteID}}">{{.Data.RouteName}}</a></h1>
+<p>{{duration .Run.Duration}}{{if .Run.StartIndex}}, partial run{{end}}{{if not .Run.StartedAt.IsZero}}, started {{date .Run.StartedAt}}{{end}}</p>
+{{with .Data.Category.Variables.Merge .Run.Variables}}<p>Variables: {{.}}</p>
+{{end}}{{with .Run.Tags}}<p>Tags: {{join .}}</p>
+{{end}}{{with .Run.Comment}}<p>{{.}}</p>
+{{end}}
+<table>
+<tr><th>Split</th><th>Segment</th><th>Split time</th><th>+/- PB</th><th>Ended</th></tr>
+{{range .Segments}}<tr><td>{{.Name}}</td><td{{if .Gold}} class="gold"{{end}}>{{duration .Duration}}</td><td>{{duration .Split}}</td><td>{{signed .PlusMinus}}</td><td>{{if not .EndedAt.IsZero}}{{.EndedAt.UTC.Format "15:04:05"}}{{end}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+`))
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/knoebber/gsplits/site"
+)
+
+// Generates a static website of every category, route and run.
+func siteCommand(args []string) error {
+	flags := flag.NewFlagSet("site", flag.ExitOnError)
+	output := flags.String("o", "site", "directory to write the website to")
+	tag := flags.String("tag", "", "only include runs with the tag")
+	flags.Parse(args)
+
+	if err := site.Generate(storage, *output, *tag); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote website to %s\n", *output)
+	return nil
+}
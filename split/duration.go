@@ -2,6 +2,7 @@ package split
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/knoebber/gsplits/db"
@@ -13,6 +14,8 @@ type Duration struct {
 	RunID    int64         `validate:"required"`
 	NameID   int64         `validate:"required"`
 	Duration time.Duration `validate:"required"`
+	EndedAt  time.Time     // When the split was made. Zero for runs saved before it was recorded.
+	Invalid  bool          // Whether the segment is left out of golds and statistics, such as after a mis-split.
 }
 
 func (Duration) String() string {
@@ -24,11 +27,88 @@ func (d *Duration) Save(tx *sql.Tx) (sql.Result, error) {
 	if err := db.Validate(d); err != nil {
 		return nil, err
 	}
-	ms := d.Duration.Nanoseconds() / 1e6
 	return tx.Exec(
-		"INSERT INTO split(run_id, split_name_id, milliseconds) VALUES (?, ?, ?)",
+		"INSERT INTO split(run_id, split_name_id, nanoseconds, ended_at) VALUES (?, ?, ?, ?)",
 		d.RunID,
 		d.NameID,
-		ms,
+		db.FromDuration(d.Duration),
+		db.NullTime(d.EndedAt),
 	)
 }
+
+// GetByRun returns the durations of the run's splits.
+// The result is ordered by the split names' positions.
+// Invalid segments are included.
+func GetByRun(q db.Querier, runID int64) ([]Duration, error) {
+	return queryDurations(q, "s.run_id = ?", runID)
+}
+
+// GetDurationsByRoute returns the durations of every split in the route's runs.
+// Invalid segments and the segments of invalid runs are left out.
+// The result is ordered by run and then by the split names' positions.
+func GetDurationsByRoute(q db.Querier, routeID int64) ([]Duration, error) {
+	return queryDurations(q, "sn.route_id = ? AND NOT s.invalid AND NOT run.invalid", routeID)
+}
+
+// SetInvalid sets whether the segment with durationID is invalid.
+func SetInvalid(q db.Querier, durationID int64, invalid bool) error {
+	res, err := q.Exec("UPDATE split SET invalid = ? WHERE id = ?", invalid, durationID)
+	if err != nil {
+		return fmt.Errorf("failed to update segment %d: %w", durationID, err)
+	}
+	return db.CheckUpdated(res, fmt.Sprintf("segment %d", durationID))
+}
+
+// GetRouteID returns the route of the segment with durationID.
+func GetRouteID(q db.Querier, durationID int64) (routeID int64, err error) {
+	err = q.QueryRow(`
+SELECT sn.route_id
+FROM split AS s
+JOIN split_name AS sn ON sn.id = s.split_name_id
+WHERE s.id = ?`, durationID).Scan(&routeID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("segment %d not found", durationID)
+	}
+	return
+}
+
+// Returns the durations that match where, which has one parameter for id.
+func queryDurations(q db.Querier, where string, id int64) ([]Duration, error) {
+	var (
+		nanoseconds int64
+		endedAt     *time.Time
+	)
+
+	rows, err := q.Query(`
+SELECT s.id, s.run_id, s.split_name_id, s.nanoseconds, s.ended_at, s.invalid
+FROM split AS s
+JOIN split_name AS sn ON sn.id = s.split_name_id
+JOIN run ON run.id = s.run_id
+WHERE `+where+`
+ORDER BY s.run_id, sn.position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get split durations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Duration{}
+	for rows.Next() {
+		curr := Duration{}
+		if err := rows.Scan(
+			&curr.ID,
+			&curr.RunID,
+			&curr.NameID,
+			&nanoseconds,
+			&endedAt,
+			&curr.Invalid,
+		); err != nil {
+			return nil, err
+		}
+		curr.Duration = db.ToDuration(nanoseconds)
+		if endedAt != nil {
+			curr.EndedAt = *endedAt
+		}
+		result = append(result, curr)
+	}
+	return result, rows.Err()
+}
@@ -13,6 +13,7 @@ type Name struct {
 	RouteID  int64  `validate:"required"`
 	Position int    `validate:"required"`
 	Name     string `validate:"required"`
+	Notes    string // Strats and reminders that are shown while the split is being run.
 }
 
 func (n Name) String() string {
@@ -34,10 +35,10 @@ func (n *Name) Save(tx *sql.Tx) (sql.Result, error) {
 
 // GetByRoute returns a list of all the split names in the route.
 // The result is ordered by position.
-func GetByRoute(routeID int64) ([]Name, error) {
+func GetByRoute(q db.Querier, routeID int64) ([]Name, error) {
 
-	rows, err := db.Connection.
-		Query("SELECT id, name FROM split_name WHERE route_id = ? ORDER BY position", routeID)
+	rows, err := q.
+		Query("SELECT id, route_id, position, name, notes FROM split_name WHERE route_id = ? ORDER BY position", routeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get split names: %w", err)
 	}
@@ -49,11 +50,23 @@ func GetByRoute(routeID int64) ([]Name, error) {
 		curr := Name{}
 		if err := rows.Scan(
 			&curr.ID,
+			&curr.RouteID,
+			&curr.Position,
 			&curr.Name,
+			&curr.Notes,
 		); err != nil {
 			return nil, err
 		}
 		result = append(result, curr)
 	}
-	return result, nil
+	return result, rows.Err()
+}
+
+// SetNotes sets the notes of the split name with splitNameID.
+func SetNotes(q db.Querier, splitNameID int64, notes string) error {
+	res, err := q.Exec("UPDATE split_name SET notes = ? WHERE id = ?", notes, splitNameID)
+	if err != nil {
+		return fmt.Errorf("failed to update split name %d: %w", splitNameID, err)
+	}
+	return db.CheckUpdated(res, fmt.Sprintf("split name %d", splitNameID))
 }
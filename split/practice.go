@@ -0,0 +1,109 @@
+package split
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/knoebber/gsplits/db"
+)
+
+// Practice is a segment that was timed in practice mode.
+// It isn't part of a run, but it can set a gold.
+type Practice struct {
+	ID       int64
+	NameID   int64         `validate:"required"`
+	Duration time.Duration `validate:"required"`
+	EndedAt  time.Time     // When the segment was finished.
+	Invalid  bool          // Whether the segment is left out of golds and statistics.
+}
+
+func (Practice) String() string {
+	return "practice segment"
+}
+
+// Save inserts the segment into the practice table.
+func (p *Practice) Save(tx *sql.Tx) (sql.Result, error) {
+	if err := db.Validate(p); err != nil {
+		return nil, err
+	}
+	return tx.Exec(
+		"INSERT INTO practice(split_name_id, nanoseconds, ended_at) VALUES (?, ?, ?)",
+		p.NameID,
+		db.FromDuration(p.Duration),
+		db.NullTime(p.EndedAt),
+	)
+}
+
+// SaveGold sets the gold of a split to the practice segment when it's faster.
+func (p *Practice) SaveGold(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+INSERT INTO gold(split_name_id, nanoseconds) VALUES (?, ?)
+ON CONFLICT(split_name_id) DO UPDATE SET nanoseconds = excluded.nanoseconds
+WHERE excluded.nanoseconds < gold.nanoseconds`, p.NameID, db.FromDuration(p.Duration))
+	if err != nil {
+		return fmt.Errorf("failed to update gold: %w", err)
+	}
+	return nil
+}
+
+// GetPractice returns the practice segments of a route's splits in the order they were saved.
+func GetPractice(q db.Querier, routeID int64) ([]Practice, error) {
+	var (
+		nanoseconds int64
+		endedAt     *time.Time
+	)
+
+	rows, err := q.Query(`
+SELECT p.id, p.split_name_id, p.nanoseconds, p.ended_at, p.invalid
+FROM practice AS p
+JOIN split_name AS sn ON sn.id = p.split_name_id
+WHERE sn.route_id = ?
+ORDER BY p.id`, routeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get practice segments: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Practice{}
+	for rows.Next() {
+		curr := Practice{}
+		if err := rows.Scan(
+			&curr.ID,
+			&curr.NameID,
+			&nanoseconds,
+			&endedAt,
+			&curr.Invalid,
+		); err != nil {
+			return nil, err
+		}
+		curr.Duration = db.ToDuration(nanoseconds)
+		if endedAt != nil {
+			curr.EndedAt = *endedAt
+		}
+		result = append(result, curr)
+	}
+	return result, rows.Err()
+}
+
+// SetPracticeInvalid sets whether the practice segment with practiceID is invalid.
+func SetPracticeInvalid(q db.Querier, practiceID int64, invalid bool) error {
+	res, err := q.Exec("UPDATE practice SET invalid = ? WHERE id = ?", invalid, practiceID)
+	if err != nil {
+		return fmt.Errorf("failed to update practice segment %d: %w", practiceID, err)
+	}
+	return db.CheckUpdated(res, fmt.Sprintf("practice segment %d", practiceID))
+}
+
+// GetPracticeRouteID returns the route of the practice segment with practiceID.
+func GetPracticeRouteID(q db.Querier, practiceID int64) (routeID int64, err error) {
+	err = q.QueryRow(`
+SELECT sn.route_id
+FROM practice AS p
+JOIN split_name AS sn ON sn.id = p.split_name_id
+WHERE p.id = ?`, practiceID).Scan(&routeID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("practice segment %d not found", practiceID)
+	}
+	return
+}
@@ -1,111 +1,189 @@
 package main
 
 import (
+	"fmt"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell"
+	"github.com/knoebber/gsplits/journal"
 	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/stats"
+	"github.com/knoebber/gsplits/timer"
 	"github.com/rivo/tview"
 )
 
 const placeholder = "___"
 
+// How many runs are simulated for the PB chance.
+// Fewer than the odds command so that a split stays fast.
+const liveSimulations = 2000
+
+// timerState draws a timer.
+// It subscribes to the timer's events to update the splits table.
 type timerState struct {
+	timer     *timer.Timer
 	routeData *route.Data
 
-	sumOfGold *time.Duration
-
-	splitIndex    int
-	runStart      time.Time
-	segmentStart  time.Time
-	segments      []time.Duration
-	totalDuration time.Duration
-
 	splitsTable          *tview.Table
 	totalTimeView        *tview.TextView
 	segmentTimeView      *tview.TextView
 	goldView             *tview.TextView
 	possibleTimeSaveView *tview.TextView
 	bestPossibleTimeView *tview.TextView
+	predictedTitleView   *tview.TextView
+	predictedTimeView    *tview.TextView
+	pbChanceView         *tview.TextView
 	sumOfGoldView        *tview.TextView
-}
+	statusView           *tview.TextView
+	notesView            *tview.TextView
 
-// Called when the run is completed.
-func (t *timerState) setTotalDuration() {
-	if t.totalDuration == 0 {
-		t.totalDuration = time.Since(t.runStart)
-	}
-}
+	// The split that notesView has the notes of.
+	// Notes are only set when the split changes so that scrolling isn't reset on each draw.
+	notesIndex int
 
-func (t *timerState) reset() {
-	for i := range t.segments {
-		t.segments[i] = 0
-	}
+	// Whether the predicted time is at the pace of the sum of best instead of the comparison.
+	// Only changed from the UI goroutine, like the views.
+	predictFromGolds bool
 
-	t.splitIndex = 0
-	t.totalDuration = 0
-	now := time.Now()
-	t.runStart = now
-	t.segmentStart = now
+	// Whether a saved run is being replayed.
+	// Replays are split from another goroutine and aren't journaled.
+	replay bool
 
-	if t.routeData.SumOfGold != nil {
-		sob := *t.routeData.SumOfGold
-		t.sumOfGold = &sob
-	}
+	// Whether segments are being practiced.
+	// Practice attempts are saved as practice segments instead of runs.
+	practice bool
 
-	t.setSplitsTable()
+	// The past times of each segment, for estimating the PB chance.
+	history [][]time.Duration
+
+	// The last PB chance and what it was simulated from.
+	// Only used from the UI goroutine.
+	pbChanceFrom pbChanceKey
+	pbChanceText string
+
+	// What happened on the timer since the last reset.
+	// Only changed by timer events, which are published one at a time.
+	events []route.Event
+
+	// Closed to stop the refresh goroutine. Nil when it isn't running.
+	stopRefresh chan struct{}
+	refreshMu   sync.Mutex
 }
 
-func (t *timerState) setTableCell(col int, value string, color tcell.Color) {
-	setTableCell(t.splitsTable, t.splitIndex, col, value, color)
+func (t *timerState) setTableCell(row, col int, value string, color tcell.Color) {
+	setTableCell(t.splitsTable, row, col, value, color)
 }
 
-func (t *timerState) getPlusMinus(total time.Duration) (plusMinus string, color tcell.Color, show bool) {
-	var lastDiff time.Duration
+func plusMinusColor(e timer.Event) tcell.Color {
+	if e.Gold {
+		return tcell.ColorGold
+	}
+	if e.PlusMinus <= 0 {
+		return tcell.ColorGreen
+	}
+	return tcell.ColorRed
+}
 
-	diff := total - t.routeData.GetComparisonSplit(t.splitIndex)
+func (t *timerState) setSplitsTable() {
+	if t.splitsTable == nil {
+		t.splitsTable = newTable()
+	}
 
-	plusMinus = durationStr(diff)
-	if diff <= 0 {
-		color = tcell.ColorGreen
-	} else {
-		color = tcell.ColorRed
+	startIndex := t.timer.StartIndex()
+	for i := range t.routeData.SplitNames {
+		if i < startIndex {
+			t.setSkippedRow(i)
+		} else {
+			t.setInactiveRow(i)
+		}
 	}
+}
 
-	if t.splitIndex > 0 {
-		lastSplit := t.segmentStart.Sub(t.runStart)
-		lastDiff = (lastSplit - t.routeData.GetComparisonSplit(t.splitIndex-1))
+// Sets a row that is before the split that the run started from.
+// It shows the comparison when the run counts the comparison's time for it and is blank otherwise.
+func (t *timerState) setSkippedRow(i int) {
+	segment, split := placeholder, placeholder
+	if t.timer.Offset() > 0 {
+		segment = durationStr(t.routeData.GetComparisonSegment(i))
+		split = durationStr(t.routeData.GetComparisonSplit(i))
 	}
 
-	if diff < 0 && lastDiff < 0 {
-		show = diff > plusMinusThreshold+lastDiff
-	} else if diff < 0 {
-		show = diff > plusMinusThreshold
-	} else {
-		show = true
+	for j, value := range []string{t.routeData.GetSplitName(i), "", segment, split} {
+		t.setTableCell(i, j, value, tcell.ColorGray)
 	}
+}
 
-	return
+// Sets a row back to the comparison values.
+func (t *timerState) setInactiveRow(i int) {
+	for j, value := range []string{
+		t.routeData.GetSplitName(i),
+		placeholder,
+		durationStr(t.routeData.GetComparisonSegment(i)),
+		durationStr(t.routeData.GetComparisonSplit(i)),
+	} {
+		t.setTableCell(i, j, value, tcell.ColorDefault)
+	}
+}
 
+// Records a timer event with the run.
+func (t *timerState) addEvent(e timer.Event) {
+	t.events = append(t.events, route.Event{
+		RouteID:    t.routeData.RouteID,
+		Type:       e.Type.String(),
+		SplitIndex: e.Index,
+		Time:       e.Time,
+	})
 }
 
-func (t *timerState) setSplitsTable() {
-	if t.splitsTable == nil {
-		t.splitsTable = newTable()
+// Updates the view after the timer changes.
+func (t *timerState) onEvent(e timer.Event) {
+	if t.replay {
+		app.QueueUpdateDraw(func() { t.applyEvent(e) })
+		return
 	}
+	t.applyEvent(e)
+}
 
-	for i := range t.routeData.SplitNames {
-		for j, value := range []string{
-			t.routeData.GetSplitName(i),
-			placeholder,
-			durationStr(t.routeData.GetComparisonSegment(i)),
-			durationStr(t.routeData.GetComparisonSplit(i)),
-		} {
-			setTableCell(t.splitsTable, i, j, value, tcell.ColorDefault)
+func (t *timerState) applyEvent(e timer.Event) {
+	if e.Type != timer.Reset {
+		t.addEvent(e)
+	}
+
+	switch e.Type {
+	case timer.Split, timer.Finish:
+		t.setTableCell(e.Index, 0, t.routeData.GetSplitName(e.Index), tcell.ColorDefault)
+		t.setTableCell(e.Index, 1, durationStr(e.PlusMinus), plusMinusColor(e))
+		t.setTableCell(e.Index, 2, durationStr(e.Segment), tcell.ColorDefault)
+		t.setTableCell(e.Index, 3, durationStr(e.Split), tcell.ColorDefault)
+		if e.Type == timer.Finish {
+			t.endRefresh()
+			t.totalTimeView.SetText(durationStr(e.Split))
+			t.predictedTimeView.SetText(durationStr(e.Split))
 		}
+		t.writeJournal()
+
+	case timer.Undo:
+		if e.Index+1 < t.routeData.Length {
+			t.setInactiveRow(e.Index + 1)
+		}
+		t.setInactiveRow(e.Index)
+		t.startRefresh()
+		t.writeJournal()
+
+	case timer.Reset:
+		t.setSplitsTable()
+		t.startRefresh()
+		t.saveReset(e)
+		t.clearJournal()
 	}
 }
 
+func (t *timerState) showError(err error) {
+	t.statusView.SetText(err.Error()).SetTextColor(tcell.ColorRed)
+}
+
 func (t *timerState) createLayout() *tview.Grid {
 
 	grid := tview.NewGrid()
@@ -118,76 +196,270 @@ func (t *timerState) createLayout() *tview.Grid {
 	row += tableRowSpan + 1 // One extra for some space.
 
 	for _, val := range []struct {
-		title string
+		title tview.Primitive
 		item  tview.Primitive
 	}{
-		{"Total Time", t.totalTimeView},
-		{"Segment Time", t.segmentTimeView},
-		{"Gold", t.goldView},
-		{"Possible Time Save", t.possibleTimeSaveView},
-		{"Best Possible Time", t.bestPossibleTimeView},
-		{"Sum Of Gold", t.sumOfGoldView},
+		{newText("Total Time"), t.totalTimeView},
+		{newText("Segment Time"), t.segmentTimeView},
+		{newText("Gold"), t.goldView},
+		{newText("Possible Time Save"), t.possibleTimeSaveView},
+		{t.predictedTitleView, t.predictedTimeView},
+		{newText("Best Possible Time"), t.bestPossibleTimeView},
+		{newText("PB Chance"), t.pbChanceView},
+		{newText("Sum Of Gold"), t.sumOfGoldView},
 	} {
 
-		grid.AddItem(newText(val.title), row, 0, 1, 2, 0, 0, false)
+		grid.AddItem(val.title, row, 0, 1, 2, 0, 0, false)
 		grid.AddItem(val.item, row, 3, 1, 1, 0, 0, false)
 		row++
 	}
+	grid.AddItem(t.statusView, row, 0, 1, 4, 0, 0, false)
+	row++
+
+	// The notes pane is left out for routes without notes.
+	if t.routeData.HasNotes() {
+		notesRowSpan := 6
+		grid.AddItem(t.notesView, row, 0, notesRowSpan, 4, 0, 0, false)
+	}
 
 	return grid
 }
 
+// Returns when the run is predicted to finish, at the pace of the comparison or the sum of best.
+func (t *timerState) predictedTime(snapshot timer.Snapshot) string {
+	if snapshot.Done {
+		return durationStr(snapshot.Elapsed)
+	}
+
+	predicted := snapshot.PredictedTime
+	if t.predictFromGolds {
+		predicted = snapshot.PredictedByGolds
+	}
+	if predicted == 0 {
+		return fmt.Sprintf("%*s", minDurationLength, "N/A")
+	}
+	return durationStr(predicted)
+}
+
+// Switches the predicted time between the pace of the comparison and the sum of best.
+func (t *timerState) togglePrediction() {
+	t.predictFromGolds = !t.predictFromGolds
+	if t.predictFromGolds {
+		t.predictedTitleView.SetText("Predicted Time (Sum Of Best)")
+	} else {
+		t.predictedTitleView.SetText("Predicted Time")
+	}
+	t.predictedTimeView.SetText(t.predictedTime(t.timer.Snapshot()))
+}
+
+// What the PB chance depends on.
+// Within a split the chance only changes when the active segment runs past one of its past times,
+// or every second once the segment is slower than all of them.
+type pbChanceKey struct {
+	splitIndex int
+	lastSplit  time.Duration
+	passed     int
+	overrun    time.Duration
+}
+
+// Returns the chance of finishing under the personal best from where the run is.
+// It's cached between draws since simulating runs takes much longer than drawing.
+func (t *timerState) pbChance(snapshot timer.Snapshot) string {
+	if t.routeData.RouteBestTime == nil || snapshot.SplitIndex >= len(t.history) {
+		return fmt.Sprintf("%*s", minDurationLength, "N/A")
+	}
+
+	key := pbChanceKey{splitIndex: snapshot.SplitIndex, lastSplit: snapshot.LastSplit + snapshot.Shift}
+	for _, past := range t.history[snapshot.SplitIndex] {
+		if past <= snapshot.SegmentElapsed {
+			key.passed++
+		}
+	}
+	if key.passed == len(t.history[snapshot.SplitIndex]) {
+		key.overrun = snapshot.SegmentElapsed.Truncate(time.Second)
+	}
+	if t.pbChanceText != "" && key == t.pbChanceFrom {
+		return t.pbChanceText
+	}
+	t.pbChanceFrom = key
+	t.pbChanceText = t.simulatePBChance(snapshot)
+	return t.pbChanceText
+}
+
+func (t *timerState) simulatePBChance(snapshot timer.Snapshot) string {
+	// A fixed seed keeps the chance from flickering between splits.
+	// A run from a later split without the comparison's time counts the comparison's time for the splits it skipped.
+	odds, err := stats.SimulateLive(
+		t.history[snapshot.SplitIndex:],
+		snapshot.LastSplit+snapshot.Shift,
+		snapshot.SegmentElapsed,
+		*t.routeData.RouteBestTime,
+		liveSimulations,
+		rand.New(rand.NewSource(1)),
+	)
+	if err != nil {
+		return fmt.Sprintf("%*s", minDurationLength, "N/A")
+	}
+	return fmt.Sprintf("%*.1f%%", minDurationLength-1, odds.PBChance*100)
+}
+
 func (t *timerState) getDrawFunc() func() {
 	return func() {
+		snapshot := t.timer.Snapshot()
 
-		runDuration := time.Since(t.runStart)
-		lastSplit := t.segmentStart.Sub(t.runStart)
-		diff := runDuration - t.routeData.GetComparisonSplit(t.splitIndex)
+		// A draw can be queued just before the run finishes.
+		// The finish event already filled in the last row.
+		if snapshot.Done {
+			return
+		}
 
 		// Draw the current split row.
-		plusMinus, plusMinusColor, showPlusMinus := t.getPlusMinus(runDuration)
+		splitIndex := snapshot.SplitIndex
+		t.setTableCell(splitIndex, 0, t.routeData.GetSplitName(splitIndex), tcell.ColorYellow)
+		if snapshot.ShowPlusMinus {
+			color := tcell.ColorGreen
+			if snapshot.PlusMinus > 0 {
+				color = tcell.ColorRed
+			}
+			t.setTableCell(splitIndex, 1, durationStr(snapshot.PlusMinus), color)
+		}
 
-		splitName := t.routeData.GetSplitName(t.splitIndex)
-		setTableCell(t.splitsTable, t.splitIndex, 0, splitName, tcell.ColorYellow)
-		if showPlusMinus {
-			setTableCell(t.splitsTable, t.splitIndex, 1, plusMinus, plusMinusColor)
+		t.totalTimeView.SetText(durationStr(snapshot.Elapsed))
+		t.segmentTimeView.SetText(durationStr(snapshot.SegmentElapsed))
+		t.goldView.SetText(durationStr(t.routeData.GetGold(splitIndex)))
+		t.possibleTimeSaveView.SetText(durationStr(t.routeData.GetTimeSave(splitIndex)))
+		t.bestPossibleTimeView.SetText(durationStr(snapshot.BestPossibleTime))
+		t.predictedTimeView.SetText(t.predictedTime(snapshot))
+		t.pbChanceView.SetText(t.pbChance(snapshot))
+		t.sumOfGoldView.SetText(safeDurationStr(snapshot.SumOfGold))
+		if splitIndex != t.notesIndex {
+			t.notesIndex = splitIndex
+			t.notesView.SetText(t.routeData.GetNotes(splitIndex)).ScrollToBeginning()
 		}
+	}
+}
+
+// Starts redrawing the timer every refresh interval.
+// It does nothing when the timer is already refreshing, so there is only ever one ticker.
+func (t *timerState) startRefresh() {
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+
+	if t.stopRefresh != nil {
+		return
+	}
+	t.stopRefresh = make(chan struct{})
+	go refresh(t.getDrawFunc(), t.stopRefresh)
+}
+
+// Stops redrawing the timer.
+func (t *timerState) endRefresh() {
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+
+	if t.stopRefresh == nil {
+		return
+	}
+	close(t.stopRefresh)
+	t.stopRefresh = nil
+}
 
-		t.totalTimeView.SetText(elapsedStr(t.runStart))
-		t.segmentTimeView.SetText(elapsedStr(t.segmentStart))
-		t.goldView.SetText(durationStr(t.routeData.GetGold(t.splitIndex)))
-		t.possibleTimeSaveView.SetText(durationStr(t.routeData.GetTimeSave(t.splitIndex)))
-		t.bestPossibleTimeView.SetText(durationStr(t.routeData.GetBPT(t.splitIndex, lastSplit, diff)))
-		t.sumOfGoldView.SetText(safeDurationStr(t.sumOfGold))
+// Saves a reset on its own since the attempt that it ended isn't saved as a run.
+func (t *timerState) saveReset(e timer.Event) {
+	t.events = nil
+	if !t.journaled() {
+		return
+	}
+	if _, err := storage.Events().Save(&route.Event{
+		RouteID:    t.routeData.RouteID,
+		Type:       e.Type.String(),
+		SplitIndex: e.Index,
+		Time:       e.Time,
+	}); err != nil {
+		t.showError(err)
 	}
 }
 
-func (t *timerState) isDone() bool {
-	// The run is finished once the last segment time is filled in.
-	return t.segments[len(t.segments)-1] != 0
+// Returns the run that is in progress.
+func (t *timerState) journalRun() *journal.Run {
+	return &journal.Run{
+		RouteID:    t.routeData.RouteID,
+		Tag:        t.routeData.Tag,
+		Comparison: comparisonRunID(t.routeData),
+		StartIndex: t.timer.StartIndex(),
+		Offset:     t.timer.Offset(),
+		Start:      t.timer.Start(),
+		Segments:   t.timer.Segments(),
+		SplitTimes: t.timer.SplitTimes(),
+		Events:     t.events,
+		Paused:     t.timer.Paused(),
+	}
 }
 
-func newTimerState(routeData *route.Data) *timerState {
-	now := time.Now()
+func (t *timerState) clearJournal() {
+	if !t.journaled() {
+		return
+	}
+	if err := journal.Clear(); err != nil {
+		t.showError(err)
+	}
+}
+
+// Returns whether the timer is timing a run that can be saved.
+func (t *timerState) journaled() bool {
+	return !t.replay && !t.practice
+}
+
+// Writes the run to the journal so it can be recovered if gsplits exits before it's saved.
+func (t *timerState) writeJournal() {
+	if !t.journaled() {
+		return
+	}
+	if err := journal.Save(t.journalRun()); err != nil {
+		t.showError(err)
+	}
+}
+
+func newTimerState(tm *timer.Timer) *timerState {
+	routeData := tm.RouteData()
 
 	t := &timerState{
+		timer:                tm,
 		routeData:            routeData,
-		splitIndex:           0,
-		runStart:             now,
-		segmentStart:         now,
-		segments:             make([]time.Duration, routeData.Length),
-		totalTimeView:        newText(elapsedStr(now)),
-		segmentTimeView:      newText(elapsedStr(now)),
+		totalTimeView:        newText(durationStr(0)),
+		segmentTimeView:      newText(durationStr(0)),
 		goldView:             newText(durationStr(routeData.GetGold(0))),
 		possibleTimeSaveView: newText(durationStr(routeData.GetTimeSave(0))),
 		bestPossibleTimeView: newText(durationStr(routeData.GetGold(0))),
+		predictedTitleView:   newText("Predicted Time"),
+		predictedTimeView:    newText(""),
+		pbChanceView:         newText(""),
 		sumOfGoldView:        newText(safeDurationStr(routeData.SumOfGold)),
+		statusView:           newText(""),
+		notesIndex:           -1,
 	}
-	if routeData.SumOfGold != nil {
-		sob := *routeData.SumOfGold
-		t.sumOfGold = &sob
+	t.notesView = tview.NewTextView().SetWordWrap(true)
+	t.notesView.SetBorder(true).SetTitle("Notes")
+	if run := routeData.ComparisonRun; run != nil {
+		// The splits are from the run, but the best times and PB chance are still of the best run.
+		t.statusView.SetText(fmt.Sprintf("Splits compared to run %d from %s", run.ID, run.CreatedAt.Local().Format("Jan 2 2006")))
 	}
 
+	history, err := getHistory(routeData)
+	if err != nil {
+		t.showError(err)
+	}
+	t.history = history
+
 	t.setSplitsTable()
+	tm.Subscribe(t.onEvent)
 	return t
 }
+
+// Returns the ID of the run that was picked as the comparison, or zero when it is the best run.
+func comparisonRunID(routeData *route.Data) int64 {
+	if routeData.ComparisonRun == nil {
+		return 0
+	}
+	return routeData.ComparisonRun.ID
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/timer"
+)
+
+func TestPBChanceShift(t *testing.T) {
+	s := time.Second
+	pb := 6 * s
+	state := &timerState{
+		routeData: &route.Data{RouteBestTime: &pb},
+		history:   [][]time.Duration{{1 * s}, {2 * s}, {3 * s}},
+	}
+
+	// A run that started blank from the second split finishes at 5s of its own time,
+	// which is the PB once the comparison's 1s for the first split is added.
+	tests := []struct {
+		shift time.Duration
+		want  string
+	}{
+		{shift: 0, want: "100.0%"},
+		{shift: 1 * s, want: "0.0%"},
+	}
+	for _, tt := range tests {
+		snapshot := timer.Snapshot{SplitIndex: 1, Shift: tt.shift}
+		if got := strings.TrimSpace(state.pbChance(snapshot)); got != tt.want {
+			t.Errorf("PB chance with a %s shift = %s, want %s", tt.shift, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MinOutlierSamples is the least amount of times that Outliers looks for outliers in.
+const MinOutlierSamples = 5
+
+// OutlierDeviations is how many standard deviations below the median a time has to be to be an outlier.
+const OutlierDeviations = 3
+
+// madScale turns a median absolute deviation into a standard deviation of normally distributed times.
+const madScale = 1.4826
+
+// meanADScale turns a mean absolute deviation into a standard deviation of normally distributed times.
+const meanADScale = 1.2533
+
+// minFallbackSpread is the least standard deviation, as a fraction of the median, when the median absolute deviation is zero.
+// It keeps times that are only a little faster than a run of identical times from being outliers.
+const minFallbackSpread = 0.01
+
+// Outlier is a time that is suspiciously fast compared to the others, such as a mis-split.
+type Outlier struct {
+	Index      int // The index of the time in the slice passed to Outliers.
+	Duration   time.Duration
+	Median     time.Duration
+	StdDev     time.Duration // The standard deviation estimated from the median absolute deviation.
+	Deviations float64       // How many standard deviations below the median the time is.
+}
+
+// Outliers returns the times that are more than OutlierDeviations standard deviations below their median.
+//
+// The standard deviation is estimated from the median absolute deviation,
+// so a single impossible time can't hide itself by widening the spread.
+// When most of the times are identical the median absolute deviation is zero,
+// so the mean absolute deviation is used instead, with a floor of minFallbackSpread.
+// Nothing is returned for fewer than MinOutlierSamples times.
+func Outliers(times []time.Duration) []Outlier {
+	result := []Outlier{}
+	if len(times) < MinOutlierSamples {
+		return result
+	}
+
+	median := Summarize(times).Median
+	deviations := make([]float64, len(times))
+	for i, t := range times {
+		deviations[i] = math.Abs(float64(t - median))
+	}
+	sort.Float64s(deviations)
+
+	var mad float64
+	if n := len(deviations); n%2 == 1 {
+		mad = deviations[n/2]
+	} else {
+		mad = (deviations[n/2-1] + deviations[n/2]) / 2
+	}
+	stdDev := mad * madScale
+	if stdDev == 0 {
+		var sum float64
+		for _, d := range deviations {
+			sum += d
+		}
+		stdDev = math.Max(sum/float64(len(deviations))*meanADScale, float64(median)*minFallbackSpread)
+	}
+	if stdDev == 0 {
+		return result
+	}
+
+	for i, t := range times {
+		d := float64(median-t) / stdDev
+		if d > OutlierDeviations {
+			result = append(result, Outlier{
+				Index:      i,
+				Duration:   t,
+				Median:     median,
+				StdDev:     time.Duration(stdDev),
+				Deviations: d,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutliers(t *testing.T) {
+	s := time.Second
+	ms := time.Millisecond
+
+	tests := []struct {
+		name        string
+		times       []time.Duration
+		wantIndexes []int
+	}{
+		{
+			name:        "normal spread",
+			times:       []time.Duration{10 * s, 11 * s, 9 * s, 10500 * ms, 9500 * ms, 2 * s},
+			wantIndexes: []int{5},
+		},
+		{
+			name:        "normal spread without outliers",
+			times:       []time.Duration{10 * s, 11 * s, 9 * s, 10500 * ms, 9500 * ms, 8 * s},
+			wantIndexes: []int{},
+		},
+		{
+			// Most times are identical, so the median absolute deviation is 0.
+			name:        "zero MAD",
+			times:       []time.Duration{10 * s, 10 * s, 10 * s, 10 * s, 10 * s, 2 * s},
+			wantIndexes: []int{5},
+		},
+		{
+			name:        "zero MAD with a close time",
+			times:       []time.Duration{10 * s, 10 * s, 10 * s, 10 * s, 10 * s, 9900 * ms},
+			wantIndexes: []int{},
+		},
+		{
+			name:        "identical times",
+			times:       []time.Duration{10 * s, 10 * s, 10 * s, 10 * s, 10 * s},
+			wantIndexes: []int{},
+		},
+		{
+			name:        "too few samples",
+			times:       []time.Duration{10 * s, 10 * s, 10 * s, 2 * s},
+			wantIndexes: []int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outliers := Outliers(test.times)
+			if len(outliers) != len(test.wantIndexes) {
+				t.Fatalf("Outliers(%v) = %v, want indexes %v", test.times, outliers, test.wantIndexes)
+			}
+			for i, o := range outliers {
+				if o.Index != test.wantIndexes[i] {
+					t.Errorf("outlier %d index = %d, want %d", i, o.Index, test.wantIndexes[i])
+				}
+				if o.Duration != test.times[o.Index] {
+					t.Errorf("outlier %d duration = %s, want %s", i, o.Duration, test.times[o.Index])
+				}
+				if o.StdDev <= 0 || o.Deviations <= OutlierDeviations {
+					t.Errorf("outlier %d = %+v, want more than %d positive standard deviations", i, o, OutlierDeviations)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+)
+
+// Recommendation is how much practicing a segment is expected to save.
+type Recommendation struct {
+	Index       int // The split index of the segment.
+	Name        string
+	TimeSave    time.Duration // The PB segment minus the gold.
+	LossRate    float64       // The fraction of times that were slower than the PB segment.
+	AverageLoss time.Duration // The time lost to the PB segment on an average attempt. Equal to LossRate times the mean loss of the slower attempts.
+	StdDev      time.Duration
+	Score       time.Duration // TimeSave + AverageLoss + StdDev, weighted equally. Higher is more worth practicing.
+}
+
+// Recommend ranks the segments of a route by how much time practicing them is expected to save.
+//
+// A segment's score is
+//
+//	TimeSave + AverageLoss + StdDev
+//
+// which is the time it could save on the PB, plus the time that is usually lost to the PB segment,
+// plus its standard deviation, so inconsistent segments rank above ones that only have a lucky gold.
+// The terms are all durations and are weighted equally.
+// LossRate isn't a separate term because AverageLoss already scales with it:
+// it's the loss rate times the mean loss of the attempts that were slower than the PB segment.
+// history must have a slice of past times for each split in d.
+// Segments without a PB segment or history are left out.
+func Recommend(d *route.Data, history [][]time.Duration) []Recommendation {
+	result := []Recommendation{}
+
+	for i, times := range history {
+		pbSegment := d.GetComparisonSegment(i)
+		if pbSegment == 0 || len(times) == 0 {
+			continue
+		}
+
+		r := Recommendation{
+			Index:    i,
+			Name:     d.GetSplitName(i),
+			TimeSave: d.GetTimeSave(i),
+			StdDev:   Summarize(times).StdDev,
+		}
+
+		losses := 0
+		var lost float64
+		for _, t := range times {
+			if t > pbSegment {
+				losses++
+				lost += float64(t - pbSegment)
+			}
+		}
+		r.LossRate = float64(losses) / float64(len(times))
+		r.AverageLoss = time.Duration(math.Round(lost / float64(len(times))))
+		r.Score = r.TimeSave + r.AverageLoss + r.StdDev
+
+		result = append(result, r)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	return result
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// Returns route data with a split for each PB segment and gold.
+func practiceTestData(pbSegments, golds []time.Duration) *route.Data {
+	d := &route.Data{Length: len(pbSegments)}
+	for i, segment := range pbSegments {
+		d.SplitNames = append(d.SplitNames, split.Name{ID: int64(i + 1), Name: string(rune('a' + i))})
+		d.ComparisonSegments = append(d.ComparisonSegments, segment)
+		d.Golds = append(d.Golds, golds[i])
+		d.TimeSaves = append(d.TimeSaves, segment-golds[i])
+	}
+	return d
+}
+
+func TestRecommend(t *testing.T) {
+	s := time.Second
+	ms := time.Millisecond
+	d := practiceTestData([]time.Duration{10 * s, 10 * s, 10 * s}, []time.Duration{8 * s, 9500 * ms, 10 * s})
+
+	tests := []struct {
+		name      string
+		history   [][]time.Duration
+		wantOrder []string
+	}{
+		{
+			// a's gap to its gold is one lucky time, while b often loses time to the PB segment.
+			name: "inconsistent above lucky gold",
+			history: [][]time.Duration{
+				{10 * s, 10 * s, 10 * s, 10 * s, 8 * s},
+				{9500 * ms, 10 * s, 12 * s, 14 * s, 11 * s},
+				{10 * s},
+			},
+			wantOrder: []string{"b", "a", "c"},
+		},
+		{
+			name:      "segments without history are left out",
+			history:   [][]time.Duration{{10 * s, 8 * s}, {}, {}},
+			wantOrder: []string{"a"},
+		},
+		{
+			name:      "empty history",
+			history:   [][]time.Duration{{}, {}, {}},
+			wantOrder: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Recommend(d, tt.history)
+			if got == nil {
+				t.Fatal("Recommend() = nil, want an empty slice")
+			}
+
+			names := []string{}
+			for _, r := range got {
+				names = append(names, r.Name)
+				if r.Score != r.TimeSave+r.AverageLoss+r.StdDev {
+					t.Errorf("%s: score = %s, want the sum of %s, %s and %s", r.Name, r.Score, r.TimeSave, r.AverageLoss, r.StdDev)
+				}
+			}
+			if len(names) != len(tt.wantOrder) {
+				t.Fatalf("order = %v, want %v", names, tt.wantOrder)
+			}
+			for i := range names {
+				if names[i] != tt.wantOrder[i] {
+					t.Fatalf("order = %v, want %v", names, tt.wantOrder)
+				}
+			}
+		})
+	}
+
+	// 3 of b's 5 times lost 2s, 4s and 1s.
+	b := Recommend(d, [][]time.Duration{{}, {9500 * ms, 10 * s, 12 * s, 14 * s, 11 * s}, {}})[0]
+	if b.LossRate != 0.6 || b.AverageLoss != 1400*ms || b.TimeSave != 500*ms {
+		t.Errorf("recommendation = %+v, want a loss rate of 0.6 and an average loss of 1.4s", b)
+	}
+}
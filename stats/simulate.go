@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/knoebber/gsplits/split"
+)
+
+// DefaultSimulations is how many runs are simulated when estimating odds.
+const DefaultSimulations = 10000
+
+// ErrNoHistory is returned when a segment has never been completed, so runs can't be simulated.
+var ErrNoHistory = errors.New("every segment needs at least one time to simulate runs")
+
+// Odds are the outcomes of simulated runs.
+type Odds struct {
+	Simulations int
+	PBChance    float64       // The fraction of runs that finished under the personal best.
+	Expected    time.Duration // The mean finish time.
+	P10         time.Duration // 10% of runs finished faster than this.
+	P25         time.Duration
+	Median      time.Duration
+	P75         time.Duration
+	P90         time.Duration
+}
+
+// History groups a route's segment times by split.
+// The result has a slice for each of names, in the same order.
+func History(names []split.Name, durations []split.Duration) [][]time.Duration {
+	index := map[int64]int{}
+	for i, sn := range names {
+		index[sn.ID] = i
+	}
+
+	result := make([][]time.Duration, len(names))
+	for _, d := range durations {
+		if i, ok := index[d.NameID]; ok {
+			result[i] = append(result[i], d.Duration)
+		}
+	}
+	return result
+}
+
+// Simulate estimates how runs will finish by sampling a time for each segment from its history.
+// Segments are sampled independently of each other.
+//
+// history holds the past times of the segments that are left to run, and elapsed is the time the run already has.
+// Pass the whole route's history and zero to simulate runs from the start.
+// pb is the time to beat; the PB chance is zero when it's zero.
+func Simulate(history [][]time.Duration, elapsed, pb time.Duration, simulations int, rnd *rand.Rand) (Odds, error) {
+	return simulate(history, elapsed, 0, pb, simulations, rnd)
+}
+
+// SimulateLive estimates how a run in progress will finish.
+// history holds the past times of the active segment and the segments after it.
+// lastSplit is the run time at the last split and segmentElapsed is how long the active segment has been running.
+// The active segment is sampled from its past times that are longer than segmentElapsed,
+// and takes segmentElapsed when it has already run longer than all of them.
+func SimulateLive(history [][]time.Duration, lastSplit, segmentElapsed, pb time.Duration, simulations int, rnd *rand.Rand) (Odds, error) {
+	return simulate(history, lastSplit, segmentElapsed, pb, simulations, rnd)
+}
+
+// Simulates runs where the first segment takes longer than floor.
+func simulate(history [][]time.Duration, elapsed, floor, pb time.Duration, simulations int, rnd *rand.Rand) (Odds, error) {
+	odds := Odds{Simulations: simulations}
+	if simulations <= 0 {
+		return odds, errors.New("simulations must be greater than zero")
+	}
+	for _, times := range history {
+		if len(times) == 0 {
+			return odds, ErrNoHistory
+		}
+	}
+
+	// The times of the first segment that it has already run past can't happen any more.
+	var active []time.Duration
+	if floor > 0 && len(history) > 0 {
+		active = longerThan(history[0], floor)
+	}
+
+	finishes := make([]time.Duration, simulations)
+	beat := 0
+	var sum float64
+	for i := range finishes {
+		finish := elapsed
+		for j, times := range history {
+			if j == 0 && floor > 0 {
+				if len(active) == 0 {
+					finish += floor
+					continue
+				}
+				times = active
+			}
+			finish += times[rnd.Intn(len(times))]
+		}
+		if finish < pb {
+			beat++
+		}
+		sum += float64(finish)
+		finishes[i] = finish
+	}
+
+	sort.Slice(finishes, func(i, j int) bool { return finishes[i] < finishes[j] })
+	percentile := func(p float64) time.Duration {
+		return finishes[int(p*float64(simulations-1))]
+	}
+
+	odds.PBChance = float64(beat) / float64(simulations)
+	odds.Expected = time.Duration(sum / float64(simulations))
+	odds.P10 = percentile(0.1)
+	odds.P25 = percentile(0.25)
+	odds.Median = percentile(0.5)
+	odds.P75 = percentile(0.75)
+	odds.P90 = percentile(0.9)
+	return odds, nil
+}
+
+// Returns the times that are longer than floor, in their original order.
+func longerThan(times []time.Duration, floor time.Duration) []time.Duration {
+	var result []time.Duration
+	for _, t := range times {
+		if t > floor {
+			result = append(result, t)
+		}
+	}
+	return result
+}
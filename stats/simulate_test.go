@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	s := time.Second
+	history := [][]time.Duration{{1 * s, 2 * s, 3 * s}, {4 * s, 5 * s, 6 * s}}
+
+	odds, err := Simulate(history, 0, 7*s, DefaultSimulations, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 of the 9 equally likely runs finish under 7s, and the mean is 7s.
+	if math.Abs(odds.PBChance-1.0/3) > 0.02 {
+		t.Errorf("PB chance = %.3f, want about 0.333", odds.PBChance)
+	}
+	if diff := odds.Expected - 7*s; diff < -100*time.Millisecond || diff > 100*time.Millisecond {
+		t.Errorf("expected finish = %s, want about 7s", odds.Expected)
+	}
+	percentiles := []time.Duration{5 * s, odds.P10, odds.P25, odds.Median, odds.P75, odds.P90, 9 * s}
+	for i := 1; i < len(percentiles); i++ {
+		if percentiles[i] < percentiles[i-1] {
+			t.Errorf("percentiles out of order: %v", percentiles[1:6])
+			break
+		}
+	}
+	if odds.Median != 7*s {
+		t.Errorf("median = %s, want 7s", odds.Median)
+	}
+
+	// The same seed gives the same odds.
+	again, err := Simulate(history, 0, 7*s, DefaultSimulations, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != odds {
+		t.Errorf("odds with the same seed = %+v, want %+v", again, odds)
+	}
+
+	// Without segments left, every run finishes at the elapsed time.
+	odds, err = Simulate(nil, 3*s, 5*s, 10, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if odds.PBChance != 1 || odds.P10 != 3*s || odds.P90 != 3*s || odds.Expected != 3*s {
+		t.Errorf("odds without segments left = %+v", odds)
+	}
+
+	if odds, err := Simulate(history, 0, 0, 10, rand.New(rand.NewSource(1))); err != nil || odds.PBChance != 0 {
+		t.Errorf("odds without a personal best = %+v, %v, want a zero PB chance", odds, err)
+	}
+	if _, err := Simulate([][]time.Duration{{s}, {}}, 0, s, 10, rand.New(rand.NewSource(1))); err != ErrNoHistory {
+		t.Errorf("error for a segment without times = %v, want %v", err, ErrNoHistory)
+	}
+	if _, err := Simulate(history, 0, s, 0, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("simulated zero runs")
+	}
+}
+
+func TestSimulateLive(t *testing.T) {
+	s := time.Second
+	history := [][]time.Duration{{1 * s, 3 * s, 5 * s}, {10 * s}}
+
+	tests := []struct {
+		name           string
+		segmentElapsed time.Duration
+		wantMin        time.Duration
+		wantMax        time.Duration
+	}{
+		{"not started", 0, 12 * s, 16 * s},
+		{"past the fastest time", 2 * s, 14 * s, 16 * s},
+		{"past every time but the slowest", 4 * s, 16 * s, 16 * s},
+		{"slower than every time", 7 * s, 18 * s, 18 * s},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			odds, err := SimulateLive(history, 1*s, tt.segmentElapsed, 15*s, 1000, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if odds.P10 < tt.wantMin || odds.P90 > tt.wantMax {
+				t.Errorf("finishes from %s to %s, want between %s and %s", odds.P10, odds.P90, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+
+	if _, err := SimulateLive([][]time.Duration{{}}, 0, 0, s, 10, rand.New(rand.NewSource(1))); err != ErrNoHistory {
+		t.Errorf("error = %v, want %v", err, ErrNoHistory)
+	}
+}
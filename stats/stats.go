@@ -0,0 +1,54 @@
+// Package stats summarizes segment and run times.
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Summary describes a set of durations.
+type Summary struct {
+	Count  int
+	Best   time.Duration // The shortest duration.
+	Worst  time.Duration // The longest duration.
+	Mean   time.Duration
+	Median time.Duration
+	StdDev time.Duration // The population standard deviation.
+}
+
+// Summarize returns a summary of durations.
+// The summary is zero when durations is empty.
+func Summarize(durations []time.Duration) (s Summary) {
+	s.Count = len(durations)
+	if s.Count == 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, s.Count)
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s.Best = sorted[0]
+	s.Worst = sorted[s.Count-1]
+
+	if s.Count%2 == 1 {
+		s.Median = sorted[s.Count/2]
+	} else {
+		s.Median = (sorted[s.Count/2-1] + sorted[s.Count/2]) / 2
+	}
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	mean := sum / float64(s.Count)
+	s.Mean = time.Duration(mean)
+
+	var variance float64
+	for _, d := range sorted {
+		variance += (float64(d) - mean) * (float64(d) - mean)
+	}
+	s.StdDev = time.Duration(math.Sqrt(variance / float64(s.Count)))
+	return
+}
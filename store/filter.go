@@ -0,0 +1,91 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// GetTaggedData returns the route's data with the comparison and golds from only the runs tagged with tag.
+// It is the same as Routes().GetData when tag is empty.
+func GetTaggedData(s Store, routeID int64, tag string) (*route.Data, error) {
+	d, err := s.Routes().GetData(routeID)
+	if err != nil || tag == "" {
+		return d, err
+	}
+
+	runs, durations, err := GetTaggedRuns(s, routeID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	best, err := GetTaggedCategoryBest(s, d.Category.ID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// The category is copied so the best time of every run isn't overwritten.
+	c := *d.Category
+	c.Best = best
+	d.Category = &c
+	d.Tag = tag
+	return d.FromRuns(runs, durations), nil
+}
+
+// GetTaggedCategoryBest returns the best time of the runs tagged with tag in every route of the category.
+// It is nil when none of the runs are full and valid.
+func GetTaggedCategoryBest(s Store, categoryID int64, tag string) (*time.Duration, error) {
+	routes, err := s.Routes().GetByCategory(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *time.Duration
+	for _, r := range routes {
+		runs, err := s.Runs().GetByRoute(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		runs, _ = route.FilterByTag(runs, nil, tag)
+		if run := route.BestRun(runs); run != nil && (best == nil || run.Duration < *best) {
+			duration := run.Duration
+			best = &duration
+		}
+	}
+	return best, nil
+}
+
+// GetTaggedRuns returns the runs of the route that are tagged with tag and their valid segments.
+// Every run is returned when tag is empty.
+func GetTaggedRuns(s Store, routeID int64, tag string) ([]route.Run, []split.Duration, error) {
+	runs, err := s.Runs().GetByRoute(routeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	durations, err := s.Splits().GetDurations(routeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runs, durations = route.FilterByTag(runs, durations, tag)
+	return runs, durations, nil
+}
+
+// CompareToRun returns a copy of d that is compared to the run with runID instead of the best run.
+func CompareToRun(s Store, d *route.Data, runID int64) (*route.Data, error) {
+	run, err := s.Runs().Get(runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("run %d not found", runID)
+	}
+
+	segments, err := s.Runs().GetSegments(runID)
+	if err != nil {
+		return nil, err
+	}
+	return d.CompareTo(*run, segments)
+}
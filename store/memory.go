@@ -0,0 +1,853 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// Memory is a store that keeps everything in memory.
+// It is safe to use from multiple goroutines.
+type Memory struct {
+	mu sync.Mutex
+
+	lastID     int64
+	games      []game.Name
+	categories []category.Name
+	routes     []route.Name
+	splitNames []split.Name
+	runs       []route.Run
+	durations  []split.Duration
+	events     []route.Event
+	practice   []split.Practice
+}
+
+// NewMemory returns an empty memory store.
+func NewMemory() *Memory {
+	return new(Memory)
+}
+
+// Games returns the game store.
+func (m *Memory) Games() Games {
+	return memoryGames{m}
+}
+
+// Categories returns the category store.
+func (m *Memory) Categories() Categories {
+	return memoryCategories{m}
+}
+
+// Routes returns the route store.
+func (m *Memory) Routes() Routes {
+	return memoryRoutes{m}
+}
+
+// Splits returns the split store.
+func (m *Memory) Splits() Splits {
+	return memorySplits{m}
+}
+
+// Runs returns the run store.
+func (m *Memory) Runs() Runs {
+	return memoryRuns{m}
+}
+
+// Events returns the event store.
+func (m *Memory) Events() Events {
+	return memoryEvents{m}
+}
+
+// Practice returns the practice store.
+func (m *Memory) Practice() Practice {
+	return memoryPractice{m}
+}
+
+func (m *Memory) nextID() int64 {
+	m.lastID++
+	return m.lastID
+}
+
+func (m *Memory) category(categoryID int64) *category.Name {
+	for i := range m.categories {
+		if m.categories[i].ID == categoryID {
+			return &m.categories[i]
+		}
+	}
+	return nil
+}
+
+// Returns a copy of c with its game's name, as if it was loaded from a database.
+func (m *Memory) loadCategory(c category.Name) category.Name {
+	c.Game = ""
+	for _, g := range m.games {
+		if g.ID == c.GameID {
+			c.Game = g.Name
+		}
+	}
+	c.Variables = game.Variables(nil).Merge(c.Variables)
+	return c
+}
+
+// Returns an error when the category's game doesn't exist.
+func (m *Memory) checkGame(c *category.Name) error {
+	if c.GameID == 0 {
+		return nil
+	}
+	for _, g := range m.games {
+		if g.ID == c.GameID {
+			return nil
+		}
+	}
+	return fmt.Errorf("game %d not found", c.GameID)
+}
+
+func (m *Memory) route(routeID int64) *route.Name {
+	for i := range m.routes {
+		if m.routes[i].ID == routeID {
+			return &m.routes[i]
+		}
+	}
+	return nil
+}
+
+// Returns the route's split names ordered by position.
+func (m *Memory) routeSplitNames(routeID int64) []split.Name {
+	result := []split.Name{}
+	for _, sn := range m.splitNames {
+		if sn.RouteID == routeID {
+			result = append(result, sn)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Position < result[j].Position
+	})
+	return result
+}
+
+// Returns whether a segment counts for golds and statistics.
+func (m *Memory) isValid(duration split.Duration) bool {
+	if duration.Invalid {
+		return false
+	}
+	for _, run := range m.runs {
+		if run.ID == duration.RunID {
+			return !run.Invalid
+		}
+	}
+	return false
+}
+
+func (m *Memory) hasSplitName(splitNameID int64) bool {
+	for _, sn := range m.splitNames {
+		if sn.ID == splitNameID {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the best full run in the route, or nil when it has no runs.
+// Ties go to the earliest run.
+func (m *Memory) bestRun(routeID int64) *route.Run {
+	var best *route.Run
+	for i, run := range m.runs {
+		if run.RouteID != routeID || !run.IsFull() || run.Invalid {
+			continue
+		}
+		if best == nil ||
+			run.Duration < best.Duration ||
+			run.Duration == best.Duration && run.ID < best.ID {
+			best = &m.runs[i]
+		}
+	}
+	return best
+}
+
+type memoryGames struct {
+	*Memory
+}
+
+func (m memoryGames) Save(g *game.Name) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := db.Validate(g); err != nil {
+		return 0, fmt.Errorf("failed to save %s: %w", g, err)
+	}
+	for _, existing := range m.games {
+		if existing.Name == g.Name {
+			return 0, fmt.Errorf("failed to save %s: name is taken", g)
+		}
+	}
+
+	saved := game.Name{ID: m.nextID(), Name: g.Name}
+	m.games = append(m.games, saved)
+	return saved.ID, nil
+}
+
+func (m memoryGames) All() ([]game.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := append([]game.Name{}, m.games...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (m memoryGames) GetByName(name string) (*game.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, g := range m.games {
+		if g.Name == name {
+			found := g
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+type memoryCategories struct {
+	*Memory
+}
+
+func (m memoryCategories) Save(c *category.Name) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkCategory(c); err != nil {
+		return 0, err
+	}
+	return m.insertCategory(c), nil
+}
+
+// Returns an error when the category can't be saved.
+func (m *Memory) checkCategory(c *category.Name) error {
+	if err := db.Validate(c); err != nil {
+		return fmt.Errorf("failed to save %s: %w", c, err)
+	}
+	for _, existing := range m.categories {
+		if existing.Name == c.Name {
+			return fmt.Errorf("failed to save %s: name is taken", c)
+		}
+	}
+	if err := m.checkGame(c); err != nil {
+		return fmt.Errorf("failed to save %s: %w", c, err)
+	}
+	return nil
+}
+
+// Inserts a category that was checked with checkCategory and returns its ID.
+func (m *Memory) insertCategory(c *category.Name) int64 {
+	saved := category.Name{
+		ID:        m.nextID(),
+		GameID:    c.GameID,
+		Name:      c.Name,
+		Variables: game.Variables(nil).Merge(c.Variables),
+	}
+	m.categories = append(m.categories, saved)
+	return saved.ID
+}
+
+func (m memoryCategories) Update(c *category.Name) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := db.Validate(c); err != nil {
+		return fmt.Errorf("failed to update %s: %w", c, err)
+	}
+	for _, existing := range m.categories {
+		if existing.Name == c.Name && existing.ID != c.ID {
+			return fmt.Errorf("failed to update %s: name is taken", c)
+		}
+	}
+	if err := m.checkGame(c); err != nil {
+		return fmt.Errorf("failed to update %s: %w", c, err)
+	}
+
+	existing := m.category(c.ID)
+	if existing == nil {
+		return fmt.Errorf("%s not found", c)
+	}
+	existing.Name = c.Name
+	existing.GameID = c.GameID
+	existing.Variables = game.Variables(nil).Merge(c.Variables)
+	return nil
+}
+
+func (m memoryCategories) All() ([]category.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []category.Name{}
+	for _, c := range m.categories {
+		c = m.loadCategory(c)
+		hasRoute := false
+		for _, r := range m.routes {
+			if r.CategoryID != c.ID {
+				continue
+			}
+			hasRoute = true
+			if best := m.bestRun(r.ID); best != nil && (c.Best == nil || best.Duration < *c.Best) {
+				dur := best.Duration
+				c.Best = &dur
+			}
+		}
+		if hasRoute {
+			result = append(result, c)
+		}
+	}
+
+	// Grouped by game like the sqlite store, with categories that aren't in a game last.
+	sort.SliceStable(result, func(i, j int) bool {
+		if (result[i].Game == "") != (result[j].Game == "") {
+			return result[j].Game == ""
+		}
+		return result[i].Game < result[j].Game
+	})
+	return result, nil
+}
+
+func (m memoryCategories) GetByName(name string) (*category.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.categories {
+		if c.Name == name {
+			found := m.loadCategory(c)
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+type memoryRoutes struct {
+	*Memory
+}
+
+func (m memoryRoutes) Save(r *route.Name, splitNames []string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := db.Validate(r); err != nil {
+		return 0, fmt.Errorf("failed to save %s: %w", r, err)
+	}
+	if err := m.checkRoute(r, splitNames); err != nil {
+		return 0, err
+	}
+	return m.insertRoute(r, splitNames), nil
+}
+
+func (m memoryRoutes) SaveWithCategory(c *category.Name, r *route.Name, splitNames []string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Both are checked before either is inserted, like a rolled back transaction.
+	// The route's category ID is only set once the category is inserted.
+	if err := m.checkCategory(c); err != nil {
+		return 0, err
+	}
+	if err := m.checkRoute(r, splitNames); err != nil {
+		return 0, err
+	}
+	r.CategoryID = m.insertCategory(c)
+	return m.insertRoute(r, splitNames), nil
+}
+
+// Returns an error when the route or one of its split names can't be saved.
+// The IDs that are set on insert aren't checked.
+func (m *Memory) checkRoute(r *route.Name, splitNames []string) error {
+	if err := db.ValidateExcept(r, "CategoryID"); err != nil {
+		return fmt.Errorf("failed to save %s: %w", r, err)
+	}
+	for _, existing := range m.routes {
+		if existing.Name == r.Name {
+			return fmt.Errorf("failed to save %s: name is taken", r)
+		}
+	}
+	for i, name := range splitNames {
+		sn := split.Name{Position: i + 1, Name: name}
+		if err := db.ValidateExcept(sn, "RouteID"); err != nil {
+			return fmt.Errorf("failed to save %s: %w", sn, err)
+		}
+	}
+	return nil
+}
+
+// Inserts a route that was checked with checkRoute and returns its ID.
+func (m *Memory) insertRoute(r *route.Name, splitNames []string) int64 {
+	saved := route.Name{ID: m.nextID(), CategoryID: r.CategoryID, Name: r.Name}
+	m.routes = append(m.routes, saved)
+	for i, name := range splitNames {
+		m.splitNames = append(m.splitNames, split.Name{
+			ID:       m.nextID(),
+			RouteID:  saved.ID,
+			Position: i + 1,
+			Name:     name,
+		})
+	}
+	return saved.ID
+}
+
+func (m memoryRoutes) All() ([]route.Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []route.Match{}
+	for _, r := range m.routes {
+		match := route.Match{Name: r}
+		if c := m.category(r.CategoryID); c != nil {
+			match.CategoryName = c.Name
+			match.GameName = m.loadCategory(*c).Game
+		}
+		for _, run := range m.runs {
+			if run.RouteID == r.ID && (match.LastRun == nil || run.CreatedAt.After(*match.LastRun)) {
+				createdAt := run.CreatedAt
+				match.LastRun = &createdAt
+			}
+		}
+		result = append(result, match)
+	}
+	return result, nil
+}
+
+func (m memoryRoutes) GetByCategory(categoryID int64) ([]route.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []route.Name
+	for _, r := range m.routes {
+		if r.CategoryID == categoryID {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (m memoryRoutes) GetData(routeID int64) (*route.Data, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if routeID == 0 {
+		return nil, errors.New("id is required")
+	}
+
+	r := m.route(routeID)
+	if r == nil {
+		return nil, route.ErrNotFound
+	}
+	splitNames := m.routeSplitNames(routeID)
+	if len(splitNames) == 0 {
+		return nil, route.ErrNotFound
+	}
+
+	d := &route.Data{
+		RouteName:          r.Name,
+		RouteID:            r.ID,
+		Category:           &category.Name{ID: r.CategoryID},
+		SplitNames:         splitNames,
+		ComparisonSplits:   []time.Duration{},
+		ComparisonSegments: []time.Duration{},
+		Golds:              []time.Duration{},
+		TimeSaves:          []time.Duration{},
+		Length:             len(splitNames),
+	}
+
+	if c := m.category(r.CategoryID); c != nil {
+		loaded := m.loadCategory(*c)
+		d.Category = &loaded
+	}
+	for _, other := range m.routes {
+		if other.CategoryID != r.CategoryID {
+			continue
+		}
+		if best := m.bestRun(other.ID); best != nil && (d.Category.Best == nil || best.Duration < *d.Category.Best) {
+			dur := best.Duration
+			d.Category.Best = &dur
+		}
+	}
+
+	for _, run := range m.runs {
+		if run.RouteID == routeID {
+			d.TotalRuns++
+		}
+	}
+
+	best := m.bestRun(routeID)
+	if best == nil {
+		return d, nil
+	}
+	routeBest := best.Duration
+	d.RouteBestTime = &routeBest
+	d.BestRunID = best.ID
+
+	golds := map[int64]time.Duration{}
+	bestSegments := map[int64]time.Duration{}
+	for _, duration := range m.durations {
+		if duration.RunID == best.ID {
+			bestSegments[duration.NameID] = duration.Duration
+		}
+		if !m.isValid(duration) {
+			continue
+		}
+		if gold, ok := golds[duration.NameID]; !ok || duration.Duration < gold {
+			golds[duration.NameID] = duration.Duration
+		}
+	}
+	for _, p := range m.practice {
+		if p.Invalid {
+			continue
+		}
+		if gold, ok := golds[p.NameID]; !ok || p.Duration < gold {
+			golds[p.NameID] = p.Duration
+		}
+	}
+
+	// Missing segments and golds are zero so that the slices stay indexed by split.
+	for _, sn := range splitNames {
+		d.AddComparison(bestSegments[sn.ID], golds[sn.ID])
+	}
+
+	return d, nil
+}
+
+type memorySplits struct {
+	*Memory
+}
+
+func (m memorySplits) GetByRoute(routeID int64) ([]split.Name, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.routeSplitNames(routeID), nil
+}
+
+func (m memorySplits) GetDurations(routeID int64) ([]split.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Durations are saved by run in the order of the route's split names.
+	result := []split.Duration{}
+	for _, sn := range m.routeSplitNames(routeID) {
+		for _, duration := range m.durations {
+			if duration.NameID == sn.ID && m.isValid(duration) {
+				result = append(result, duration)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RunID < result[j].RunID
+	})
+	return result, nil
+}
+
+type memoryRuns struct {
+	*Memory
+}
+
+func (m memoryRuns) Save(run *route.Run, segments []split.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *run
+	if saved.CreatedAt.IsZero() {
+		saved.CreatedAt = time.Now()
+	}
+	if err := db.Validate(saved); err != nil {
+		return 0, fmt.Errorf("failed to save %s: %w", run, err)
+	}
+
+	splitNames := m.routeSplitNames(run.RouteID)
+	if run.StartIndex < 0 || run.StartIndex+len(segments) > len(splitNames) {
+		return 0, fmt.Errorf(
+			"run has %d segments from split %d but the route has %d splits",
+			len(segments),
+			run.StartIndex,
+			len(splitNames),
+		)
+	}
+
+	saved.ID = m.nextID()
+	saved.CreatedAt = saved.CreatedAt.UTC()
+	saved.StartedAt = saved.StartedAt.UTC()
+	saved.Events = nil
+	saved.Tags = route.NormalizeTags(run.Tags)
+	saved.Variables = game.Variables(nil).Merge(run.Variables)
+
+	// The segments and events are copied so that the caller's aren't changed.
+	savedSegments := append([]split.Duration{}, segments...)
+	for i := range savedSegments {
+		savedSegments[i].RunID = saved.ID
+		savedSegments[i].NameID = splitNames[run.StartIndex+i].ID
+		savedSegments[i].EndedAt = savedSegments[i].EndedAt.UTC()
+		if err := db.Validate(savedSegments[i]); err != nil {
+			return 0, fmt.Errorf("failed to save %s: %w", savedSegments[i], err)
+		}
+	}
+	savedEvents := append([]route.Event{}, run.Events...)
+	for i := range savedEvents {
+		savedEvents[i].RunID = saved.ID
+		savedEvents[i].RouteID = run.RouteID
+		savedEvents[i].Time = savedEvents[i].Time.UTC()
+		if err := db.Validate(savedEvents[i]); err != nil {
+			return 0, fmt.Errorf("failed to save %s: %w", savedEvents[i], err)
+		}
+	}
+
+	for _, segment := range savedSegments {
+		segment.ID = m.nextID()
+		m.durations = append(m.durations, segment)
+	}
+	for _, e := range savedEvents {
+		e.ID = m.nextID()
+		m.events = append(m.events, e)
+	}
+	m.runs = append(m.runs, saved)
+	return saved.ID, nil
+}
+
+func (m memoryRuns) Get(runID int64) (*route.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, run := range m.runs {
+		if run.ID == runID {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m memorySplits) SetInvalid(durationID int64, invalid bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.durations {
+		if m.durations[i].ID == durationID {
+			m.durations[i].Invalid = invalid
+			return nil
+		}
+	}
+	return fmt.Errorf("segment %d not found", durationID)
+}
+
+func (m memorySplits) SetNotes(splitNameID int64, notes string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.splitNames {
+		if m.splitNames[i].ID == splitNameID {
+			m.splitNames[i].Notes = notes
+			return nil
+		}
+	}
+	return fmt.Errorf("split name %d not found", splitNameID)
+}
+
+func (m memoryRuns) GetByRoute(routeID int64) ([]route.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []route.Run{}
+	for _, run := range m.runs {
+		if run.RouteID == routeID {
+			result = append(result, run)
+		}
+	}
+	return result, nil
+}
+
+func (m memoryRuns) Annotate(runID int64, comment string, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].ID == runID {
+			m.runs[i].Comment = comment
+			m.runs[i].Tags = route.NormalizeTags(tags)
+			return nil
+		}
+	}
+	return fmt.Errorf("run %d not found", runID)
+}
+
+func (m memoryRuns) SetVariables(runID int64, v game.Variables) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].ID == runID {
+			m.runs[i].Variables = game.Variables(nil).Merge(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("run %d not found", runID)
+}
+
+func (m memoryRuns) SetInvalid(runID int64, invalid bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].ID == runID {
+			m.runs[i].Invalid = invalid
+			return nil
+		}
+	}
+	return fmt.Errorf("run %d not found", runID)
+}
+
+func (m memoryRuns) Delete(runID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.runs {
+		if m.runs[i].ID != runID {
+			continue
+		}
+		m.runs = append(m.runs[:i], m.runs[i+1:]...)
+
+		durations := m.durations[:0]
+		for _, duration := range m.durations {
+			if duration.RunID != runID {
+				durations = append(durations, duration)
+			}
+		}
+		m.durations = durations
+
+		events := m.events[:0]
+		for _, e := range m.events {
+			if e.RunID != runID {
+				events = append(events, e)
+			}
+		}
+		m.events = events
+		return nil
+	}
+	return fmt.Errorf("run %d not found", runID)
+}
+
+func (m memoryRuns) GetSegments(runID int64) ([]split.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Durations are saved in the order of the route's split names.
+	result := []split.Duration{}
+	for _, duration := range m.durations {
+		if duration.RunID == runID {
+			result = append(result, duration)
+		}
+	}
+	return result, nil
+}
+
+type memoryEvents struct {
+	*Memory
+}
+
+func (m memoryEvents) Save(e *route.Event) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := db.Validate(e); err != nil {
+		return 0, fmt.Errorf("failed to save %s: %w", e, err)
+	}
+
+	saved := *e
+	saved.ID = m.nextID()
+	saved.Time = saved.Time.UTC()
+	m.events = append(m.events, saved)
+	return saved.ID, nil
+}
+
+func (m memoryEvents) GetByRun(runID int64) ([]route.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []route.Event
+	for _, e := range m.events {
+		if e.RunID == runID {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+func (m memoryEvents) GetByRoute(routeID int64) ([]route.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []route.Event
+	for _, e := range m.events {
+		if e.RouteID == routeID {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+type memoryPractice struct {
+	*Memory
+}
+
+func (m memoryPractice) Save(p *split.Practice) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := db.Validate(p); err != nil {
+		return 0, fmt.Errorf("failed to save %s: %w", p, err)
+	}
+	if !m.hasSplitName(p.NameID) {
+		return 0, fmt.Errorf("failed to save %s: split name %d not found", p, p.NameID)
+	}
+
+	saved := *p
+	saved.ID = m.nextID()
+	saved.EndedAt = saved.EndedAt.UTC()
+	m.practice = append(m.practice, saved)
+	return saved.ID, nil
+}
+
+func (m memoryPractice) GetByRoute(routeID int64) ([]split.Practice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []split.Practice{}
+	for _, p := range m.practice {
+		for _, sn := range m.routeSplitNames(routeID) {
+			if p.NameID == sn.ID {
+				result = append(result, p)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m memoryPractice) SetInvalid(practiceID int64, invalid bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.practice {
+		if m.practice[i].ID == practiceID {
+			m.practice[i].Invalid = invalid
+			return nil
+		}
+	}
+	return fmt.Errorf("practice segment %d not found", practiceID)
+}
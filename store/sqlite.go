@@ -0,0 +1,495 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// SQLite is a store that uses a sqlite3 database.
+type SQLite struct {
+	conn *sql.DB
+}
+
+// NewSQLite returns a store that uses conn.
+// conn should be opened with db.Open so that its tables exist.
+func NewSQLite(conn *sql.DB) *SQLite {
+	return &SQLite{conn: conn}
+}
+
+// Games returns the game store.
+func (s *SQLite) Games() Games {
+	return sqliteGames{s.conn}
+}
+
+// Categories returns the category store.
+func (s *SQLite) Categories() Categories {
+	return sqliteCategories{s.conn}
+}
+
+// Routes returns the route store.
+func (s *SQLite) Routes() Routes {
+	return sqliteRoutes{s.conn}
+}
+
+// Splits returns the split store.
+func (s *SQLite) Splits() Splits {
+	return sqliteSplits{s.conn}
+}
+
+// Runs returns the run store.
+func (s *SQLite) Runs() Runs {
+	return sqliteRuns{s.conn}
+}
+
+// Events returns the event store.
+func (s *SQLite) Events() Events {
+	return sqliteEvents{s.conn}
+}
+
+// Practice returns the practice store.
+func (s *SQLite) Practice() Practice {
+	return sqlitePractice{s.conn}
+}
+
+type saver interface {
+	String() string
+	Save(tx *sql.Tx) (sql.Result, error)
+}
+
+func save(s saver, tx *sql.Tx) (id int64, err error) {
+	var res sql.Result
+
+	res, err = s.Save(tx)
+	if err != nil {
+		return 0, saveError(s, tx, err)
+	}
+
+	id, err = res.LastInsertId()
+	if err != nil {
+		return 0, idError(s, tx, err)
+	}
+	return
+}
+
+func idError(s saver, tx *sql.Tx, err error) error {
+	return fmt.Errorf("failed to get ID from %s: %w", s, db.Rollback(tx, err))
+}
+
+func saveError(s saver, tx *sql.Tx, err error) error {
+	return fmt.Errorf("failed to save %s: %w", s, db.Rollback(tx, err))
+}
+
+type sqliteGames struct {
+	conn *sql.DB
+}
+
+func (s sqliteGames) Save(g *game.Name) (gameID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save game transaction: %w", err)
+	}
+
+	if gameID, err = save(g, tx); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqliteGames) All() ([]game.Name, error) {
+	return game.All(s.conn)
+}
+
+func (s sqliteGames) GetByName(name string) (*game.Name, error) {
+	return game.GetByName(s.conn, name)
+}
+
+type sqliteCategories struct {
+	conn *sql.DB
+}
+
+func (s sqliteCategories) Save(c *category.Name) (categoryID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save category transaction: %w", err)
+	}
+
+	if categoryID, err = save(c, tx); err != nil {
+		return
+	}
+	if err = category.SaveVariables(tx, categoryID, c.Variables); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqliteCategories) Update(c *category.Name) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start update category transaction: %w", err)
+	}
+
+	if err := c.Update(tx); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteCategories) All() ([]category.Name, error) {
+	return category.All(s.conn)
+}
+
+func (s sqliteCategories) GetByName(name string) (*category.Name, error) {
+	return category.GetByName(s.conn, name)
+}
+
+type sqliteRoutes struct {
+	conn *sql.DB
+}
+
+func (s sqliteRoutes) Save(r *route.Name, splitNames []string) (routeID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save route transaction: %w", err)
+	}
+
+	if routeID, err = saveRoute(tx, r, splitNames); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqliteRoutes) SaveWithCategory(c *category.Name, r *route.Name, splitNames []string) (routeID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save route transaction: %w", err)
+	}
+
+	if r.CategoryID, err = save(c, tx); err != nil {
+		return
+	}
+	if err = category.SaveVariables(tx, r.CategoryID, c.Variables); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+	if routeID, err = saveRoute(tx, r, splitNames); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// Inserts the route and its split names.
+// The transaction is rolled back when it fails.
+func saveRoute(tx *sql.Tx, r *route.Name, splitNames []string) (routeID int64, err error) {
+	routeID, err = save(r, tx)
+	if err != nil {
+		return
+	}
+
+	sn := &split.Name{
+		RouteID: routeID,
+	}
+	for i, splitName := range splitNames {
+		sn.Position = i + 1
+		sn.Name = splitName
+
+		_, err = save(sn, tx)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return
+}
+
+func (s sqliteRoutes) All() ([]route.Match, error) {
+	return route.All(s.conn)
+}
+
+func (s sqliteRoutes) GetByCategory(categoryID int64) ([]route.Name, error) {
+	return route.GetByCategory(s.conn, categoryID)
+}
+
+func (s sqliteRoutes) GetData(routeID int64) (*route.Data, error) {
+	return route.GetData(s.conn, routeID)
+}
+
+type sqliteSplits struct {
+	conn *sql.DB
+}
+
+func (s sqliteSplits) GetByRoute(routeID int64) ([]split.Name, error) {
+	return split.GetByRoute(s.conn, routeID)
+}
+
+func (s sqliteSplits) GetDurations(routeID int64) ([]split.Duration, error) {
+	return split.GetDurationsByRoute(s.conn, routeID)
+}
+
+func (s sqliteSplits) SetInvalid(durationID int64, invalid bool) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start invalid segment transaction: %w", err)
+	}
+
+	routeID, err := split.GetRouteID(tx, durationID)
+	if err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := split.SetInvalid(tx, durationID, invalid); err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := route.RecomputeBests(tx, routeID); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteSplits) SetNotes(splitNameID int64, notes string) error {
+	return split.SetNotes(s.conn, splitNameID, notes)
+}
+
+type sqliteRuns struct {
+	conn *sql.DB
+}
+
+func (s sqliteRuns) Save(run *route.Run, segments []split.Duration) (runID int64, err error) {
+	var (
+		tx         *sql.Tx
+		splitNames []split.Name
+	)
+
+	splitNames, err = split.GetByRoute(s.conn, run.RouteID)
+	if err != nil {
+		return
+	}
+	if run.StartIndex < 0 || run.StartIndex+len(segments) > len(splitNames) {
+		return 0, fmt.Errorf(
+			"run has %d segments from split %d but the route has %d splits",
+			len(segments),
+			run.StartIndex,
+			len(splitNames),
+		)
+	}
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save run transaction: %w", err)
+	}
+
+	runID, err = save(run, tx)
+	if err != nil {
+		return
+	}
+
+	// The segments and events are copied so that the caller's aren't changed.
+	for i, segment := range segments {
+		segment.RunID = runID
+		segment.NameID = splitNames[run.StartIndex+i].ID
+		if _, err = save(&segment, tx); err != nil {
+			return
+		}
+	}
+
+	if err = route.SaveTags(tx, runID, run.Tags); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+	if err = route.SaveVariables(tx, runID, run.Variables); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+
+	for _, e := range run.Events {
+		e.RunID = runID
+		e.RouteID = run.RouteID
+		if _, err = save(&e, tx); err != nil {
+			return
+		}
+	}
+
+	if err = route.SaveBests(tx, runID); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqliteRuns) Get(runID int64) (*route.Run, error) {
+	return route.GetRun(s.conn, runID)
+}
+
+func (s sqliteRuns) GetByRoute(routeID int64) ([]route.Run, error) {
+	return route.GetRuns(s.conn, routeID)
+}
+
+func (s sqliteRuns) Annotate(runID int64, comment string, tags []string) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start annotate run transaction: %w", err)
+	}
+
+	if err := route.Annotate(tx, runID, comment, tags); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteRuns) SetVariables(runID int64, v game.Variables) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start run variables transaction: %w", err)
+	}
+
+	run, err := route.GetRun(tx, runID)
+	if err != nil {
+		return db.Rollback(tx, err)
+	}
+	if run == nil {
+		return db.Rollback(tx, fmt.Errorf("run %d not found", runID))
+	}
+	if err := route.SaveVariables(tx, runID, v); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteRuns) SetInvalid(runID int64, invalid bool) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start invalid run transaction: %w", err)
+	}
+
+	run, err := route.GetRun(tx, runID)
+	if err != nil {
+		return db.Rollback(tx, err)
+	}
+	if run == nil {
+		return db.Rollback(tx, fmt.Errorf("run %d not found", runID))
+	}
+	if err := route.SetInvalid(tx, runID, invalid); err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := route.RecomputeBests(tx, run.RouteID); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteRuns) Delete(runID int64) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start delete run transaction: %w", err)
+	}
+
+	run, err := route.GetRun(tx, runID)
+	if err != nil {
+		return db.Rollback(tx, err)
+	}
+	if run == nil {
+		return db.Rollback(tx, fmt.Errorf("run %d not found", runID))
+	}
+	if err := route.DeleteRun(tx, runID); err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := route.RecomputeBests(tx, run.RouteID); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
+
+func (s sqliteRuns) GetSegments(runID int64) ([]split.Duration, error) {
+	return split.GetByRun(s.conn, runID)
+}
+
+type sqliteEvents struct {
+	conn *sql.DB
+}
+
+func (s sqliteEvents) Save(e *route.Event) (eventID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save event transaction: %w", err)
+	}
+
+	if eventID, err = save(e, tx); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqliteEvents) GetByRun(runID int64) ([]route.Event, error) {
+	return route.GetEvents(s.conn, runID)
+}
+
+func (s sqliteEvents) GetByRoute(routeID int64) ([]route.Event, error) {
+	return route.GetRouteEvents(s.conn, routeID)
+}
+
+type sqlitePractice struct {
+	conn *sql.DB
+}
+
+func (s sqlitePractice) Save(p *split.Practice) (practiceID int64, err error) {
+	var tx *sql.Tx
+
+	tx, err = s.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start save practice transaction: %w", err)
+	}
+
+	if practiceID, err = save(p, tx); err != nil {
+		return
+	}
+	if err = p.SaveGold(tx); err != nil {
+		return 0, db.Rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s sqlitePractice) GetByRoute(routeID int64) ([]split.Practice, error) {
+	return split.GetPractice(s.conn, routeID)
+}
+
+func (s sqlitePractice) SetInvalid(practiceID int64, invalid bool) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start invalid practice transaction: %w", err)
+	}
+
+	routeID, err := split.GetPracticeRouteID(tx, practiceID)
+	if err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := split.SetPracticeInvalid(tx, practiceID, invalid); err != nil {
+		return db.Rollback(tx, err)
+	}
+	if err := route.RecomputeBests(tx, routeID); err != nil {
+		return db.Rollback(tx, err)
+	}
+	return tx.Commit()
+}
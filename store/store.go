@@ -0,0 +1,151 @@
+// Package store defines where gsplits keeps its data.
+// SQLite is used by the gsplits command; Memory is for embedding and tests.
+package store
+
+import (
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// Store holds every kind of gsplits data.
+type Store interface {
+	Games() Games
+	Categories() Categories
+	Routes() Routes
+	Splits() Splits
+	Runs() Runs
+	Events() Events
+	Practice() Practice
+}
+
+// Games stores the games that categories belong to.
+type Games interface {
+	// Save inserts the game and returns its ID.
+	Save(g *game.Name) (int64, error)
+
+	// All returns every game ordered by name.
+	All() ([]game.Name, error)
+
+	// GetByName returns the game with name.
+	// Returns nil when no game has the name.
+	GetByName(name string) (*game.Name, error)
+}
+
+// Categories stores speedrun categories.
+type Categories interface {
+	// Save inserts the category with its variables and returns its ID.
+	Save(c *category.Name) (int64, error)
+
+	// Update sets the name and game of the category with c.ID and replaces its variables.
+	Update(c *category.Name) error
+
+	// All returns the categories that have a route.
+	// They are grouped by game, ordered by the game's name, with categories that aren't in a game last.
+	All() ([]category.Name, error)
+
+	// GetByName returns the category with name.
+	// Returns nil when no category has the name.
+	GetByName(name string) (*category.Name, error)
+}
+
+// Routes stores routes and their split names.
+type Routes interface {
+	// Save inserts the route with a split name for each of splitNames and returns the route's ID.
+	Save(r *route.Name, splitNames []string) (int64, error)
+
+	// SaveWithCategory inserts the category and then the route in it, and returns the route's ID.
+	// Nothing is saved when either fails, so a failed route doesn't leave an empty category.
+	// r.CategoryID is set to the new category's ID.
+	SaveWithCategory(c *category.Name, r *route.Name, splitNames []string) (int64, error)
+
+	// All returns every route for searching.
+	All() ([]route.Match, error)
+
+	// GetByCategory returns the routes that are in the category.
+	GetByCategory(categoryID int64) ([]route.Name, error)
+
+	// GetData returns the route's splits, comparison and golds.
+	// The error is route.ErrNotFound when the route doesn't exist.
+	GetData(routeID int64) (*route.Data, error)
+}
+
+// Splits stores split names.
+type Splits interface {
+	// GetByRoute returns the route's split names ordered by position.
+	GetByRoute(routeID int64) ([]split.Name, error)
+
+	// GetDurations returns the segments of every run in the route.
+	// Invalid segments and the segments of invalid runs are left out.
+	// They are ordered by run and then by split name position.
+	GetDurations(routeID int64) ([]split.Duration, error)
+
+	// SetInvalid sets whether a segment is left out of golds and statistics.
+	// The route's golds are recomputed.
+	SetInvalid(durationID int64, invalid bool) error
+
+	// SetNotes sets the notes that are shown while a split is being run.
+	SetNotes(splitNameID int64, notes string) error
+}
+
+// Runs stores completed runs.
+type Runs interface {
+	// Save inserts the run with its segments and events and returns the run's ID.
+	// segments must be in the same order as the route's split names, starting from the run's start index.
+	// Their run and name IDs are set from the run and route; segments and run.Events aren't changed.
+	// Times are stored in UTC.
+	Save(run *route.Run, segments []split.Duration) (int64, error)
+
+	// Get returns the run with runID.
+	// Returns nil when the run isn't found.
+	Get(runID int64) (*route.Run, error)
+
+	// GetByRoute returns the route's runs in the order they were saved.
+	GetByRoute(routeID int64) ([]route.Run, error)
+
+	// GetSegments returns the run's segments in the order of the route's split names.
+	// Invalid segments are included.
+	GetSegments(runID int64) ([]split.Duration, error)
+
+	// Annotate sets the run's comment and replaces its tags.
+	Annotate(runID int64, comment string, tags []string) error
+
+	// SetVariables replaces the run's variables.
+	SetVariables(runID int64, v game.Variables) error
+
+	// SetInvalid sets whether a run is left out of bests and statistics.
+	// The route's golds and best run are recomputed.
+	SetInvalid(runID int64, invalid bool) error
+
+	// Delete removes the run with its segments, events, tags and variables.
+	// The route's golds and best run are recomputed.
+	Delete(runID int64) error
+}
+
+// Events stores what happened on the timer.
+type Events interface {
+	// Save inserts an event that isn't part of a saved run and returns its ID.
+	Save(e *route.Event) (int64, error)
+
+	// GetByRun returns the events of a run in the order they happened.
+	GetByRun(runID int64) ([]route.Event, error)
+
+	// GetByRoute returns every event of the route in the order they happened.
+	GetByRoute(routeID int64) ([]route.Event, error)
+}
+
+// Practice stores segments that were timed in practice mode.
+// They are kept apart from runs so that they don't change run statistics.
+type Practice interface {
+	// Save inserts the practice segment, updates the split's gold when it's faster and returns the segment's ID.
+	Save(p *split.Practice) (int64, error)
+
+	// GetByRoute returns the practice segments of the route's splits in the order they were saved.
+	// Invalid segments are included.
+	GetByRoute(routeID int64) ([]split.Practice, error)
+
+	// SetInvalid sets whether a practice segment is left out of golds and statistics.
+	// The route's golds are recomputed.
+	SetInvalid(practiceID int64, invalid bool) error
+}
@@ -0,0 +1,902 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/category"
+	"github.com/knoebber/gsplits/db"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+const ms = time.Millisecond
+
+// Runs f against an empty sqlite store and an empty memory store,
+// so that both are held to the same behavior.
+func testStores(t *testing.T, f func(t *testing.T, s Store)) {
+	dir, err := ioutil.TempDir("", "gsplits-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := db.Open(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Run("sqlite", func(t *testing.T) { f(t, NewSQLite(conn)) })
+	t.Run("memory", func(t *testing.T) { f(t, NewMemory()) })
+}
+
+// Saves a route in a new category and returns the route's ID.
+func saveTestRoute(t *testing.T, s Store, categoryName, routeName string, splitNames ...string) int64 {
+	t.Helper()
+
+	routeID, err := s.Routes().SaveWithCategory(&category.Name{Name: categoryName}, &route.Name{Name: routeName}, splitNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routeID
+}
+
+// Saves a run with segments from startIndex and returns its ID.
+func saveTestRun(t *testing.T, s Store, routeID int64, startIndex int, segments ...time.Duration) int64 {
+	t.Helper()
+
+	run := &route.Run{RouteID: routeID, StartIndex: startIndex}
+	durations := make([]split.Duration, len(segments))
+	for i, segment := range segments {
+		run.Duration += segment
+		durations[i].Duration = segment
+	}
+
+	runID, err := s.Runs().Save(run, durations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runID
+}
+
+// Returns the route's data and fails when it can't be loaded.
+func getTestData(t *testing.T, s Store, routeID int64) *route.Data {
+	t.Helper()
+
+	d, err := s.Routes().GetData(routeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func durationsEqual(t *testing.T, name string, got, want []time.Duration) {
+	t.Helper()
+
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestGamesAndCategories(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		zeldaID, err := s.Games().Save(&game.Name{Name: "Zelda"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Games().Save(&game.Name{Name: "Mario"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Games().Save(&game.Name{Name: "Zelda"}); err == nil {
+			t.Error("saved a second game named Zelda")
+		}
+
+		games, err := s.Games().All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(games) != 2 || games[0].Name != "Mario" || games[1].Name != "Zelda" {
+			t.Errorf("Games().All() = %v, want Mario and Zelda", games)
+		}
+		if g, err := s.Games().GetByName("Sonic"); g != nil || err != nil {
+			t.Errorf("GetByName of a missing game = %v, %v", g, err)
+		}
+
+		anyID, err := s.Categories().Save(&category.Name{
+			Name:      "any%",
+			GameID:    zeldaID,
+			Variables: game.Variables{game.Platform: "N64"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		looseID, err := s.Categories().Save(&category.Name{Name: "loose"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Categories().Save(&category.Name{Name: "no routes"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Categories().Save(&category.Name{Name: "any%"}); err == nil {
+			t.Error("saved a second category named any%")
+		}
+		if _, err := s.Categories().Save(&category.Name{Name: "bad game", GameID: 9999}); err == nil {
+			t.Error("saved a category in a missing game")
+		}
+
+		anyRouteID, err := s.Routes().Save(&route.Name{Name: "standard", CategoryID: anyID}, []string{"a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Routes().Save(&route.Name{Name: "other", CategoryID: looseID}, []string{"a"}); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := s.Categories().GetByName("any%")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Game != "Zelda" || c.GameID != zeldaID || !reflect.DeepEqual(c.Variables, game.Variables{game.Platform: "N64"}) {
+			t.Errorf("GetByName(any%%) = %+v", c)
+		}
+		if c, err := s.Categories().GetByName("missing"); c != nil || err != nil {
+			t.Errorf("GetByName of a missing category = %v, %v", c, err)
+		}
+
+		// Categories without a route are left out and categories without runs have no best.
+		all, err := s.Categories().All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 2 || all[0].Name != "any%" || all[1].Name != "loose" {
+			t.Fatalf("Categories().All() = %+v, want any%% and loose", all)
+		}
+		if all[0].Best != nil || all[1].Best != nil {
+			t.Errorf("categories without runs have bests %v and %v", all[0].Best, all[1].Best)
+		}
+
+		saveTestRun(t, s, anyRouteID, 0, 5*time.Second)
+		all, err = s.Categories().All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if all[0].Best == nil || *all[0].Best != 5*time.Second {
+			t.Errorf("any%% best = %v, want 5s", all[0].Best)
+		}
+
+		c.Name = "100%"
+		c.GameID = 0
+		c.Variables = nil
+		if err := s.Categories().Update(c); err != nil {
+			t.Fatal(err)
+		}
+		c, err = s.Categories().GetByName("100%")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c == nil || c.Game != "" || c.GameID != 0 || len(c.Variables) != 0 {
+			t.Errorf("updated category = %+v", c)
+		}
+		if err := s.Categories().Update(&category.Name{ID: 9999, Name: "missing"}); err == nil {
+			t.Error("updated a missing category")
+		}
+	})
+}
+
+func TestSaveWithCategory(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+
+		_, err := s.Routes().SaveWithCategory(&category.Name{Name: "orphan"}, &route.Name{Name: "route"}, []string{"a"})
+		if err == nil {
+			t.Fatal("saved a second route named route")
+		}
+		if c, err := s.Categories().GetByName("orphan"); c != nil || err != nil {
+			t.Errorf("the category of a failed route was saved: %v, %v", c, err)
+		}
+
+		_, err = s.Routes().SaveWithCategory(&category.Name{Name: "blank split"}, &route.Name{Name: "other"}, []string{"a", ""})
+		if err == nil {
+			t.Fatal("saved a route with a blank split name")
+		}
+		if c, err := s.Categories().GetByName("blank split"); c != nil || err != nil {
+			t.Errorf("the category of a route with a blank split was saved: %v, %v", c, err)
+		}
+		if _, err := s.Routes().Save(&route.Name{Name: "no category"}, []string{"a"}); err == nil {
+			t.Error("saved a route without a category")
+		}
+
+		// The new route is in the new category, whatever IDs were used before.
+		if _, err := s.Games().Save(&game.Name{Name: "game"}); err != nil {
+			t.Fatal(err)
+		}
+		otherID, err := s.Routes().SaveWithCategory(&category.Name{Name: "other"}, &route.Name{Name: "other"}, []string{"a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := getTestData(t, s, otherID); d.Category.Name != "other" || d.Length != 1 {
+			t.Errorf("GetData() of the second route = %+v", d)
+		}
+
+		d := getTestData(t, s, routeID)
+		if d.Length != 2 || d.Category.Name != "category" {
+			t.Errorf("GetData() = %+v", d)
+		}
+
+		routes, err := s.Routes().GetByCategory(d.Category.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 1 || routes[0].ID != routeID {
+			t.Errorf("GetByCategory() = %v", routes)
+		}
+	})
+}
+
+func TestRoutesAll(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		gameID, err := s.Games().Save(&game.Name{Name: "Mario"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		routeID, err := s.Routes().SaveWithCategory(
+			&category.Name{Name: "16 star", GameID: gameID},
+			&route.Name{Name: "standard"},
+			[]string{"a"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saveTestRoute(t, s, "120 star", "long", "a")
+		saveTestRun(t, s, routeID, 0, time.Second)
+
+		matches, err := s.Routes().All()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != 2 {
+			t.Fatalf("Routes().All() = %v", matches)
+		}
+
+		m := matches[0]
+		if m.ID != routeID || m.CategoryName != "16 star" || m.GameName != "Mario" || m.LastRun == nil {
+			t.Errorf("match = %+v", m)
+		}
+		if matches[1].LastRun != nil || matches[1].GameName != "" {
+			t.Errorf("match without runs or a game = %+v", matches[1])
+		}
+
+		found := route.Search(matches, "mario/16/standard")
+		if len(found) != 1 || found[0].ID != routeID {
+			t.Errorf("Search(mario/16/standard) = %v", found)
+		}
+	})
+}
+
+func TestRuns(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b", "c")
+
+		d := getTestData(t, s, routeID)
+		if d.RouteBestTime != nil || d.SumOfGold != nil || len(d.Golds) != 0 || d.BestRunID != 0 {
+			t.Errorf("route without runs has data %+v", d)
+		}
+
+		saveTestRun(t, s, routeID, 0, 1000*ms, 2000*ms, 3000*ms)
+		bestID := saveTestRun(t, s, routeID, 0, 900*ms, 2500*ms, 2000*ms)
+		saveTestRun(t, s, routeID, 0, 1100*ms, 1500*ms, 4000*ms)
+
+		d = getTestData(t, s, routeID)
+		if d.BestRunID != bestID || d.RouteBestTime == nil || *d.RouteBestTime != 5400*ms {
+			t.Errorf("best run = %d in %v, want %d in 5.4s", d.BestRunID, d.RouteBestTime, bestID)
+		}
+		if d.SumOfGold == nil || *d.SumOfGold != 4400*ms || d.TotalRuns != 3 {
+			t.Errorf("sum of gold = %v and total runs = %d, want 4.4s and 3", d.SumOfGold, d.TotalRuns)
+		}
+		if d.Category.Best == nil || *d.Category.Best != 5400*ms {
+			t.Errorf("category best = %v, want 5.4s", d.Category.Best)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{900 * ms, 1500 * ms, 2000 * ms})
+		durationsEqual(t, "ComparisonSegments", d.ComparisonSegments, []time.Duration{900 * ms, 2500 * ms, 2000 * ms})
+		durationsEqual(t, "ComparisonSplits", d.ComparisonSplits, []time.Duration{900 * ms, 3400 * ms, 5400 * ms})
+		durationsEqual(t, "TimeSaves", d.TimeSaves, []time.Duration{0, 1000 * ms, 0})
+
+		// Partial runs set golds but are never the best run.
+		partialID := saveTestRun(t, s, routeID, 1, 1000*ms, 1000*ms)
+		d = getTestData(t, s, routeID)
+		if d.BestRunID != bestID || d.TotalRuns != 4 {
+			t.Errorf("after a partial run the best run is %d of %d runs", d.BestRunID, d.TotalRuns)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{900 * ms, 1000 * ms, 1000 * ms})
+
+		partial, err := s.Runs().Get(partialID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if partial == nil || partial.StartIndex != 1 || partial.Duration != 2000*ms || partial.CreatedAt.IsZero() {
+			t.Errorf("Get(partial) = %+v", partial)
+		}
+		segments, err := s.Runs().GetSegments(partialID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(segments) != 2 || segments[0].NameID != d.SplitNames[1].ID || segments[1].NameID != d.SplitNames[2].ID {
+			t.Errorf("GetSegments(partial) = %+v", segments)
+		}
+
+		runs, err := s.Runs().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 4 || runs[1].ID != bestID || runs[3].ID != partialID {
+			t.Errorf("GetByRoute() = %+v", runs)
+		}
+
+		durations, err := s.Splits().GetDurations(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(durations) != 11 {
+			t.Errorf("GetDurations() has %d segments, want 11", len(durations))
+		}
+		for i := 1; i < len(durations); i++ {
+			if durations[i].RunID < durations[i-1].RunID {
+				t.Errorf("GetDurations() isn't ordered by run")
+			}
+		}
+
+		if run, err := s.Runs().Get(9999); run != nil || err != nil {
+			t.Errorf("Get of a missing run = %v, %v", run, err)
+		}
+		if _, err := s.Runs().Save(
+			&route.Run{RouteID: routeID, Duration: time.Second},
+			make([]split.Duration, 4),
+		); err == nil {
+			t.Error("saved a run with more segments than splits")
+		}
+	})
+}
+
+func TestBestRunTie(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		firstID := saveTestRun(t, s, routeID, 0, 1000*ms, 2000*ms)
+		saveTestRun(t, s, routeID, 0, 2000*ms, 1000*ms)
+		slowID := saveTestRun(t, s, routeID, 0, 3000*ms, 3000*ms)
+
+		checkFirst := func(when string) {
+			t.Helper()
+
+			d := getTestData(t, s, routeID)
+			if d.BestRunID != firstID {
+				t.Errorf("%s: best run = %d, want the earliest run %d", when, d.BestRunID, firstID)
+			}
+			durationsEqual(t, when+": ComparisonSegments", d.ComparisonSegments, []time.Duration{1000 * ms, 2000 * ms})
+		}
+		checkFirst("after SaveBests")
+
+		// Invalidating another run recomputes the bests from every run.
+		if err := s.Runs().SetInvalid(slowID, true); err != nil {
+			t.Fatal(err)
+		}
+		checkFirst("after RecomputeBests")
+	})
+}
+
+func TestUnfinishedRun(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b", "c")
+		bestID := saveTestRun(t, s, routeID, 0, 1000*ms, 1000*ms, 1000*ms)
+
+		// An unfinished run is faster than the best run, but only because it's missing a segment.
+		unfinishedID, err := s.Runs().Save(
+			&route.Run{RouteID: routeID, Duration: 1000 * ms, Unfinished: true},
+			[]split.Duration{{Duration: 500 * ms}, {Duration: 500 * ms}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unfinished, err := s.Runs().Get(unfinishedID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unfinished == nil || !unfinished.Unfinished || unfinished.IsFull() {
+			t.Errorf("Get(unfinished) = %+v", unfinished)
+		}
+
+		d := getTestData(t, s, routeID)
+		if d.BestRunID != bestID {
+			t.Errorf("best run = %d, want %d", d.BestRunID, bestID)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{500 * ms, 500 * ms, 1000 * ms})
+
+		// Recomputing the bests leaves it out too.
+		if err := s.Runs().SetInvalid(bestID, true); err != nil {
+			t.Fatal(err)
+		}
+		if d := getTestData(t, s, routeID); d.BestRunID != 0 || d.RouteBestTime != nil {
+			t.Errorf("best run = %d in %v after invalidating the only full run", d.BestRunID, d.RouteBestTime)
+		}
+	})
+}
+
+func TestGetDataNotFound(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		if _, err := s.Routes().GetData(9999); !errors.Is(err, route.ErrNotFound) {
+			t.Errorf("GetData(missing) error = %v, want route.ErrNotFound", err)
+		}
+	})
+}
+
+func TestInvalid(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		slowID := saveTestRun(t, s, routeID, 0, 2000*ms, 2000*ms)
+		fastID := saveTestRun(t, s, routeID, 0, 100*ms, 3000*ms)
+
+		segments, err := s.Runs().GetSegments(fastID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Splits().SetInvalid(segments[0].ID, true); err != nil {
+			t.Fatal(err)
+		}
+
+		d := getTestData(t, s, routeID)
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 2000 * ms})
+		if d.BestRunID != fastID {
+			t.Errorf("a run with an invalid segment stopped being the best run")
+		}
+		durations, err := s.Splits().GetDurations(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(durations) != 3 {
+			t.Errorf("GetDurations() has %d segments, want the 3 valid ones", len(durations))
+		}
+
+		if err := s.Runs().SetInvalid(fastID, true); err != nil {
+			t.Fatal(err)
+		}
+		d = getTestData(t, s, routeID)
+		if d.BestRunID != slowID || *d.RouteBestTime != 4000*ms {
+			t.Errorf("best run = %d, want %d after the faster run is invalid", d.BestRunID, slowID)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 2000 * ms})
+
+		if err := s.Runs().SetInvalid(fastID, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Splits().SetInvalid(segments[0].ID, false); err != nil {
+			t.Fatal(err)
+		}
+		d = getTestData(t, s, routeID)
+		if d.BestRunID != fastID {
+			t.Errorf("best run = %d, want %d after restoring it", d.BestRunID, fastID)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{100 * ms, 2000 * ms})
+
+		if err := s.Runs().SetInvalid(9999, true); err == nil {
+			t.Error("marked a missing run as invalid")
+		}
+		if err := s.Splits().SetInvalid(9999, true); err == nil {
+			t.Error("marked a missing segment as invalid")
+		}
+	})
+}
+
+func TestInvalidOnlySegment(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b", "c")
+		runID := saveTestRun(t, s, routeID, 0, 1000*ms, 2000*ms, 3000*ms)
+
+		segments, err := s.Runs().GetSegments(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Splits().SetInvalid(segments[1].ID, true); err != nil {
+			t.Fatal(err)
+		}
+
+		// b has no gold left, but c still lines up with its own split.
+		d := getTestData(t, s, routeID)
+		if d.Length != 3 {
+			t.Errorf("Length = %d, want 3", d.Length)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{1000 * ms, 0, 3000 * ms})
+		durationsEqual(t, "ComparisonSegments", d.ComparisonSegments, []time.Duration{1000 * ms, 2000 * ms, 3000 * ms})
+		durationsEqual(t, "ComparisonSplits", d.ComparisonSplits, []time.Duration{1000 * ms, 3000 * ms, 6000 * ms})
+		durationsEqual(t, "TimeSaves", d.TimeSaves, []time.Duration{0, 0, 0})
+		if d.GetGold(2) != 3000*ms {
+			t.Errorf("GetGold(2) = %s, want c's gold", d.GetGold(2))
+		}
+	})
+}
+
+func TestDeleteRun(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		slowID := saveTestRun(t, s, routeID, 0, 2000*ms, 2000*ms)
+		fastID := saveTestRun(t, s, routeID, 0, 100*ms, 3000*ms)
+
+		if err := s.Runs().Delete(fastID); err != nil {
+			t.Fatal(err)
+		}
+		d := getTestData(t, s, routeID)
+		if d.BestRunID != slowID || *d.RouteBestTime != 4000*ms {
+			t.Errorf("best run = %d, want %d after the faster run is deleted", d.BestRunID, slowID)
+		}
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 2000 * ms})
+		if run, err := s.Runs().Get(fastID); run != nil || err != nil {
+			t.Errorf("Get of a deleted run = %v, %v", run, err)
+		}
+		if segments, err := s.Runs().GetSegments(fastID); len(segments) != 0 || err != nil {
+			t.Errorf("GetSegments of a deleted run = %v, %v", segments, err)
+		}
+
+		if err := s.Runs().Delete(slowID); err != nil {
+			t.Fatal(err)
+		}
+		d = getTestData(t, s, routeID)
+		if d.BestRunID != 0 || d.RouteBestTime != nil {
+			t.Errorf("best run = %d, want none after every run is deleted", d.BestRunID)
+		}
+		durationsEqual(t, "Golds", d.Golds, nil)
+
+		if err := s.Runs().Delete(slowID); err == nil {
+			t.Error("deleted a missing run")
+		}
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a")
+
+		runID, err := s.Runs().Save(&route.Run{
+			RouteID:   routeID,
+			Duration:  time.Second,
+			Comment:   "first try",
+			Tags:      []string{"Emu", "emu", " console "},
+			Variables: game.Variables{game.Region: "PAL"},
+		}, []split.Duration{{Duration: time.Second}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		run, err := s.Runs().Get(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.Comment != "first try" ||
+			!reflect.DeepEqual(run.Tags, []string{"console", "emu"}) ||
+			!reflect.DeepEqual(run.Variables, game.Variables{game.Region: "PAL"}) {
+			t.Errorf("saved run = %+v", run)
+		}
+
+		if err := s.Runs().Annotate(runID, "", []string{"tas"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Runs().SetVariables(runID, nil); err != nil {
+			t.Fatal(err)
+		}
+		runs, err := s.Runs().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runs[0].Comment != "" || !reflect.DeepEqual(runs[0].Tags, []string{"tas"}) || len(runs[0].Variables) != 0 {
+			t.Errorf("annotated run = %+v", runs[0])
+		}
+
+		if err := s.Runs().Annotate(9999, "", nil); err == nil {
+			t.Error("annotated a missing run")
+		}
+		if err := s.Runs().SetVariables(9999, nil); err == nil {
+			t.Error("set the variables of a missing run")
+		}
+	})
+}
+
+func TestGetTaggedData(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		saveTestRun(t, s, routeID, 0, time.Second, time.Second)
+		tagged := saveTestRun(t, s, routeID, 0, 2*time.Second, time.Second)
+		if err := s.Runs().Annotate(tagged, "", []string{"emu"}); err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := GetTaggedData(s, routeID, "emu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Tag != "emu" || d.TotalRuns != 1 || d.RouteBestTime == nil || *d.RouteBestTime != 3*time.Second {
+			t.Errorf("tagged data = %+v", d)
+		}
+		if d.Category.Best == nil || *d.Category.Best != 3*time.Second {
+			t.Errorf("tagged category best = %v, want 3s", d.Category.Best)
+		}
+
+		d, err = GetTaggedData(s, routeID, "tas")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.RouteBestTime != nil || d.Category.Best != nil {
+			t.Errorf("bests without tagged runs = %v, %v", d.RouteBestTime, d.Category.Best)
+		}
+
+		d = getTestData(t, s, routeID)
+		if d.Category.Best == nil || *d.Category.Best != 2*time.Second {
+			t.Errorf("category best = %v, want 2s", d.Category.Best)
+		}
+	})
+}
+
+func TestEvents(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		if _, err := s.Events().Save(&route.Event{
+			RouteID: routeID,
+			Type:    "reset",
+			Time:    start,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Events are returned in the order they happened, not the order they were saved.
+		runID, err := s.Runs().Save(&route.Run{
+			RouteID:  routeID,
+			Duration: 2 * time.Second,
+			Events: []route.Event{
+				{Type: "finish", SplitIndex: 1, Time: start.Add(3 * time.Second)},
+				{Type: "split", SplitIndex: 0, Time: start.Add(2 * time.Second)},
+			},
+		}, []split.Duration{{Duration: time.Second}, {Duration: time.Second}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := s.Events().GetByRun(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 || events[0].Type != "split" || events[1].Type != "finish" || events[0].RunID != runID {
+			t.Errorf("GetByRun() = %+v", events)
+		}
+		if !events[0].Time.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("event time = %s, want %s", events[0].Time, start.Add(2*time.Second))
+		}
+
+		events, err = s.Events().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 3 || events[0].Type != "reset" || events[0].RunID != 0 {
+			t.Errorf("GetByRoute() = %+v", events)
+		}
+	})
+}
+
+func TestSaveRunTimes(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		start := time.Date(2020, 5, 1, 23, 30, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+		run := &route.Run{
+			RouteID:   routeID,
+			Duration:  2 * time.Second,
+			StartedAt: start,
+			Events:    []route.Event{{Type: "split", Time: start.Add(time.Second)}},
+		}
+		segments := []split.Duration{
+			{Duration: time.Second, EndedAt: start.Add(time.Second)},
+			{Duration: time.Second, EndedAt: start.Add(2 * time.Second)},
+		}
+		runID, err := s.Runs().Save(run, segments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The caller's segments and events are kept as they were.
+		if segments[0].RunID != 0 || segments[1].NameID != 0 || run.Events[0].RunID != 0 || run.Events[0].RouteID != 0 {
+			t.Errorf("Save changed the segments %+v and events %+v", segments, run.Events)
+		}
+
+		saved, err := s.Runs().Get(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		savedSegments, err := s.Runs().GetSegments(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := s.Events().GetByRun(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(savedSegments) != 2 || len(events) != 1 {
+			t.Fatalf("saved %d segments and %d events, want 2 and 1", len(savedSegments), len(events))
+		}
+
+		for _, c := range []struct {
+			name      string
+			got, want time.Time
+		}{
+			{"created at", saved.CreatedAt, saved.CreatedAt},
+			{"started at", saved.StartedAt, start},
+			{"segment ended at", savedSegments[1].EndedAt, start.Add(2 * time.Second)},
+			{"event time", events[0].Time, start.Add(time.Second)},
+		} {
+			if c.got.Location() != time.UTC || !c.got.Equal(c.want) {
+				t.Errorf("%s = %s, want %s in UTC", c.name, c.got, c.want)
+			}
+		}
+	})
+}
+
+func TestPractice(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+		saveTestRun(t, s, routeID, 0, 2000*ms, 2000*ms)
+
+		d := getTestData(t, s, routeID)
+		if _, err := s.Practice().Save(&split.Practice{NameID: d.SplitNames[1].ID, Duration: 1500 * ms}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Practice().Save(&split.Practice{NameID: d.SplitNames[0].ID, Duration: 2500 * ms}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Practice().Save(&split.Practice{NameID: 9999, Duration: time.Second}); err == nil {
+			t.Error("saved practice for a missing split")
+		}
+
+		d = getTestData(t, s, routeID)
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 1500 * ms})
+		if *d.SumOfGold != 3500*ms || *d.RouteBestTime != 4000*ms {
+			t.Errorf("sum of gold = %s and best = %s, want 3.5s and 4s", *d.SumOfGold, *d.RouteBestTime)
+		}
+
+		practice, err := s.Practice().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(practice) != 2 || practice[0].Duration != 1500*ms || practice[1].Duration != 2500*ms {
+			t.Errorf("Practice().GetByRoute() = %+v", practice)
+		}
+
+		// An invalid practice segment stops being the gold but is still listed.
+		if err := s.Practice().SetInvalid(practice[0].ID, true); err != nil {
+			t.Fatal(err)
+		}
+		d = getTestData(t, s, routeID)
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 2000 * ms})
+		practice, err = s.Practice().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(practice) != 2 || !practice[0].Invalid || practice[1].Invalid {
+			t.Errorf("Practice().GetByRoute() = %+v after marking the first invalid", practice)
+		}
+
+		if err := s.Practice().SetInvalid(practice[0].ID, false); err != nil {
+			t.Fatal(err)
+		}
+		d = getTestData(t, s, routeID)
+		durationsEqual(t, "Golds", d.Golds, []time.Duration{2000 * ms, 1500 * ms})
+
+		if err := s.Practice().SetInvalid(9999, true); err == nil {
+			t.Error("marked a missing practice segment as invalid")
+		}
+	})
+}
+
+func TestNotes(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		routeID := saveTestRoute(t, s, "category", "route", "a", "b")
+
+		names, err := s.Splits().GetByRoute(routeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 2 || names[0].Name != "a" || names[1].Position != 2 {
+			t.Fatalf("Splits().GetByRoute() = %+v", names)
+		}
+
+		if err := s.Splits().SetNotes(names[1].ID, "jump early"); err != nil {
+			t.Fatal(err)
+		}
+		d := getTestData(t, s, routeID)
+		if d.GetNotes(0) != "" || d.GetNotes(1) != "jump early" || !d.HasNotes() {
+			t.Errorf("notes = %q, %q", d.GetNotes(0), d.GetNotes(1))
+		}
+		if err := s.Splits().SetNotes(9999, ""); err == nil {
+			t.Error("set the notes of a missing split")
+		}
+	})
+}
+
+// Loads a route with 10k runs of 100 splits,
+// which was slow when golds and the best run were found by joining every split.
+func BenchmarkGetData(b *testing.B) {
+	const (
+		runCount   = 10000
+		splitCount = 100
+	)
+
+	dir, err := ioutil.TempDir("", "gsplits-store")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := db.Open(filepath.Join(dir, "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	s := NewSQLite(conn)
+
+	splitNames := make([]string, splitCount)
+	for i := range splitNames {
+		splitNames[i] = fmt.Sprintf("split %d", i)
+	}
+	routeID, err := s.Routes().SaveWithCategory(&category.Name{Name: "category"}, &route.Name{Name: "route"}, splitNames)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Saving each run with the store takes minutes, so the runs and their segments are generated in SQL.
+	tx, err := conn.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	statements := []string{
+		`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?2)
+INSERT INTO run(route_id) SELECT ?1 FROM n`,
+		`INSERT INTO split(run_id, split_name_id, nanoseconds)
+SELECT run.id, sn.id, 1000000000 + (run.id * 31 + sn.position * 17) % 1000 * 1000000
+FROM run JOIN split_name AS sn ON sn.route_id = run.route_id
+WHERE run.route_id = ?1`,
+		`UPDATE run SET nanoseconds = (SELECT SUM(nanoseconds) FROM split WHERE run_id = run.id) WHERE route_id = ?1`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, routeID, runCount); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := route.RecomputeBests(tx, routeID); err != nil {
+		b.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	d, err := s.Routes().GetData(routeID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if d.TotalRuns != runCount || len(d.Golds) != splitCount {
+		b.Fatalf("seeded %d runs with %d golds, want %d with %d", d.TotalRuns, len(d.Golds), runCount, splitCount)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Routes().GetData(routeID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,146 +2,187 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell"
+	"github.com/knoebber/gsplits/game"
+	"github.com/knoebber/gsplits/journal"
 	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/timer"
 	"github.com/rivo/tview"
 )
 
-func promptSaveRun(routeID int64, segments []time.Duration, totalDuration time.Duration) {
-	modal := tview.NewModal().
-		SetText(fmt.Sprintf("Total Time: %s\nSave Run?", durationStr(totalDuration))).
-		AddButtons([]string{"Yes", "No"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			if buttonLabel == "Yes" {
-				_, err := saveRun(routeID, segments, totalDuration)
-				if err != nil {
-					panic(err)
-				}
+// Asks whether to save a finished run, with an optional comment, tags and variables.
+// The tags start as the tag that the run was compared to.
+// Variables are only needed when they differ from the category's, such as playing on another platform.
+func promptSaveRun(run *journal.Run) {
+	var (
+		comment   string
+		tags      = run.Tag
+		variables string
+		form      *tview.Form
+	)
+
+	finish := func(save bool) {
+		if save {
+			v, err := game.ParseVariables(variables)
+			if err != nil {
+				form.SetTitle(err.Error())
+				return
 			}
-			app.Stop()
-		})
-
-	app.SetRoot(modal, false).SetFocus(modal)
-}
-
-func previousSplit(state *timerState) {
-	if state.splitIndex == 0 {
-		return
+			if _, err := saveRun(run, strings.TrimSpace(comment), route.ParseTags(tags), v); err != nil {
+				showSaveError(err)
+				return
+			}
+		}
+		if err := journal.Clear(); err != nil {
+			showSaveError(err)
+			return
+		}
+		app.Stop()
 	}
 
-	state.segments[state.splitIndex] = 0
-
-	// Make the current row inactive
-	state.setTableCell(0, state.routeData.GetSplitName(state.splitIndex), tcell.ColorDefault)
-	state.setTableCell(1, placeholder, tcell.ColorDefault)
-
-	// Make the previous row active again.
-	state.splitIndex--
-	comparisonSegment := state.routeData.GetComparisonSegment(state.splitIndex)
-	comparisonSplit := state.routeData.GetComparisonSplit(state.splitIndex)
-	state.setTableCell(1, placeholder, tcell.ColorDefault)
-	state.setTableCell(2, durationStr(comparisonSegment), tcell.ColorDefault)
-	state.setTableCell(3, durationStr(comparisonSplit), tcell.ColorDefault)
-
-	// Reset the segment time.
-	now := time.Now()
-	lastSegment := state.segments[state.splitIndex]
-	revert := now.Sub(state.segmentStart)
-	state.segmentStart = now.Add((lastSegment + revert) * -1)
-}
-
-func nextSplit(state *timerState) {
-	splitTime := time.Since(state.runStart)
-	segmentTime := time.Since(state.segmentStart)
+	form = tview.NewForm().
+		AddInputField("Comment", "", 40, nil, func(text string) { comment = text }).
+		AddInputField("Tags", tags, 40, nil, func(text string) { tags = text }).
+		AddInputField("Variables", "", 40, nil, func(text string) { variables = text }).
+		AddButton("Save", func() { finish(true) }).
+		AddButton("Discard", func() { finish(false) })
 
-	plusMinus, plusMinusColor, _ := state.getPlusMinus(splitTime)
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Total Time: %s, save run?", strings.TrimSpace(durationStr(run.Total()))))
 
-	state.setTableCell(0, state.routeData.GetSplitName(state.splitIndex), tcell.ColorDefault)
-	state.setTableCell(1, plusMinus, plusMinusColor)
-	state.setTableCell(2, durationStr(segmentTime), tcell.ColorDefault)
-	state.setTableCell(3, durationStr(splitTime), tcell.ColorDefault)
-
-	state.segments[state.splitIndex] = segmentTime
-	gold := state.routeData.GetGold(state.splitIndex)
-
-	if segmentTime < gold {
-		timeSave := gold - segmentTime
+	// Typing in the form shouldn't control the timer.
+	app.SetInputCapture(nil)
+	app.SetRoot(form, true).SetFocus(form)
+}
 
-		if state.sumOfGold != nil {
-			*state.sumOfGold -= timeSave
-		}
-		state.setTableCell(1, plusMinus, tcell.ColorGold)
-	}
+// Shows an error from saving a run.
+// The run stays in the journal so that it can be saved the next time gsplits starts.
+func showSaveError(err error) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("%s\n\nThe run is kept in the journal and can be saved when gsplits is restarted.", err)).
+		AddButtons([]string{"Quit"}).
+		SetDoneFunc(func(int, string) {
+			app.Stop()
+		})
 
-	if state.splitIndex < state.routeData.Length-1 {
-		state.segmentStart = time.Now()
-		state.splitIndex++
-	}
+	app.SetRoot(modal, false).SetFocus(modal)
 }
 
 func getInputHandler(state *timerState) func(event *tcell.EventKey) *tcell.EventKey {
 	handleNextSplit := func() {
 		// If the run is done and next split is pressed again.
-		if state.isDone() {
-			promptSaveRun(state.routeData.RouteID, state.segments, state.totalDuration)
+		if state.timer.IsDone() {
+			promptSaveRun(state.journalRun())
 			return
 		}
 
-		nextSplit(state)
-
-		// If the run is done after pushing next split.
-		if state.isDone() {
-			state.setTotalDuration()
-		}
-	}
-
-	resetRun := func() {
-		// Need to start the goroutine if the splits were already finished.
-		startThread := state.isDone()
-
-		state.reset()
-
-		if startThread {
-			go refresh(state)
-		}
+		state.timer.Split()
 	}
 
 	// Returning nil stops the input from propagating.
 	return func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
 		case 'r':
-			resetRun()
+			state.timer.Reset()
 
 		case ' ':
 			handleNextSplit()
 			return nil
+
+		case 'p':
+			state.togglePrediction()
+			return nil
 		}
 
 		switch event.Key() {
 		case tcell.KeyCtrlSpace:
-			previousSplit(state)
+			state.timer.Undo()
 		}
 		return event
 	}
 }
 
-func refresh(state *timerState) {
+// Queues draw every refresh interval until stop is closed.
+func refresh(draw func(), stop <-chan struct{}) {
 	tick := time.NewTicker(refreshInterval)
-	drawFunc := state.getDrawFunc()
+	defer tick.Stop()
 
-	for !state.isDone() {
+	for {
 		select {
 		case <-tick.C:
-			app.QueueUpdateDraw(drawFunc)
+			app.QueueUpdateDraw(draw)
+		case <-stop:
+			return
 		}
 	}
 }
 
 func startTimer(routeData *route.Data) {
-	state := newTimerState(routeData)
+	runTimer(newTimerState(timer.New(routeData, timer.SystemClock)))
+}
+
+// Starts the timer from the split at index.
+// When useComparison is true the earlier splits count as the comparison's time, otherwise the run time starts at zero.
+// The run is saved as a partial run.
+func startTimerFrom(routeData *route.Data, index int, useComparison bool) {
+	var offset time.Duration
+
+	if useComparison && index > 0 {
+		offset = routeData.GetComparisonSplit(index - 1)
+	}
+
+	state := newTimerState(timer.New(routeData, timer.SystemClock))
+	state.timer.StartFrom(index, offset)
+	state.setSplitsTable()
+	runTimer(state)
+}
+
+// Asks which split to start the run from.
+// cancel is called when the runner decides not to start.
+func showStartFromForm(routeData *route.Data, cancel func()) {
+	var (
+		index         int
+		useComparison = true
+	)
+
+	names := make([]string, len(routeData.SplitNames))
+	for i := range routeData.SplitNames {
+		names[i] = routeData.GetSplitName(i)
+	}
+
+	form := tview.NewForm().
+		AddDropDown("Start at", names, 0, func(_ string, i int) { index = i }).
+		AddDropDown("Earlier splits", []string{"Comparison", "Blank"}, 0, func(_ string, i int) { useComparison = i == 0 })
+
+	form.
+		AddButton("Start", func() {
+			startTimerFrom(routeData, index, useComparison)
+		}).
+		AddButton("Cancel", cancel)
+
+	form.SetBorder(true).SetTitle("Start from a split")
+	app.SetRoot(form, true).SetFocus(form)
+}
+
+// Starts the timer from a run that was recovered from the journal.
+// The time since the journal was last written is not counted.
+func resumeTimer(routeData *route.Data, run *journal.Run) {
+	state := newTimerState(timer.New(routeData, timer.SystemClock))
+	state.timer.StartFrom(run.StartIndex, run.Offset)
+	state.setSplitsTable()
+	state.timer.Restore(run.Segments, run.SplitTimes, run.Paused+time.Since(run.UpdatedAt))
+	// Restoring publishes a split event for each segment, use the journaled events instead.
+	state.events = append([]route.Event(nil), run.Events...)
+	state.writeJournal()
+	runTimer(state)
+}
+
+func runTimer(state *timerState) {
 	container := state.createLayout()
-	go refresh(state)
+	if !state.timer.IsDone() {
+		state.startRefresh()
+	}
 	app.SetRoot(container, true).SetInputCapture(getInputHandler(state))
 }
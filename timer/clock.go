@@ -0,0 +1,18 @@
+package timer
+
+import "time"
+
+// Clock tells the timer what time it is.
+// Tests and replays can use a clock that they control.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is a clock that uses the system time.
+var SystemClock Clock = systemClock{}
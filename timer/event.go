@@ -0,0 +1,44 @@
+package timer
+
+import "time"
+
+// EventType is the kind of change that happened to a timer.
+type EventType int
+
+const (
+	// Split is sent when a segment is completed.
+	Split EventType = iota
+	// Undo is sent when the last split is taken back.
+	Undo
+	// Reset is sent when the run is started over.
+	Reset
+	// Finish is sent when the last segment is completed.
+	Finish
+)
+
+func (e EventType) String() string {
+	switch e {
+	case Split:
+		return "split"
+	case Undo:
+		return "undo"
+	case Reset:
+		return "reset"
+	case Finish:
+		return "finish"
+	}
+	return "unknown"
+}
+
+// Event describes a change to a timer.
+// Subscribers receive events after the timer is updated.
+type Event struct {
+	Type  EventType
+	Time  time.Time // When the event happened.
+	Index int       // The split index that changed. For Undo this is the index that is active again.
+	// For Reset it is the index that the run was reset at.
+	Segment   time.Duration // Split and Finish: the duration of the completed segment.
+	Split     time.Duration // Split and Finish: the run time at the split.
+	PlusMinus time.Duration // Split and Finish: the difference from the comparison split.
+	Gold      bool          // Split and Finish: whether the segment beat the gold.
+}
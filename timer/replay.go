@@ -0,0 +1,86 @@
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayClock is a clock that runs speed times faster than the system clock.
+// Replay holds it at the exact time of each split so that replayed segments match the saved ones.
+type ReplayClock struct {
+	mu     sync.Mutex
+	speed  float64
+	held   bool
+	at     time.Time // The time that the clock was last held at.
+	anchor time.Time // The system time when the clock was last released.
+}
+
+// NewReplayClock returns a clock that starts at the system time.
+// speed must be greater than zero.
+func NewReplayClock(speed float64) *ReplayClock {
+	now := time.Now()
+	return &ReplayClock{speed: speed, at: now, anchor: now}
+}
+
+// Now returns the replayed time.
+func (c *ReplayClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.held {
+		return c.at
+	}
+	return c.at.Add(c.scale(time.Since(c.anchor)))
+}
+
+// Hold stops the clock at at until it's released.
+func (c *ReplayClock) Hold(at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.held = true
+	c.at = at
+}
+
+// Release starts the clock again from where it was held.
+func (c *ReplayClock) Release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.held = false
+	c.anchor = time.Now()
+}
+
+// Returns how much replayed time passes in real.
+func (c *ReplayClock) scale(real time.Duration) time.Duration {
+	return time.Duration(float64(real) * c.speed)
+}
+
+// Returns how much system time it takes for replayed to pass.
+func (c *ReplayClock) unscale(replayed time.Duration) time.Duration {
+	return time.Duration(float64(replayed) / c.speed)
+}
+
+// Replay splits t at the end of each segment as if the run was live.
+// t must use clock and be freshly started.
+// It returns when every segment is split or stop is closed.
+func Replay(t *Timer, clock *ReplayClock, segments []time.Duration, stop <-chan struct{}) {
+	start := t.Start()
+
+	var split time.Duration
+	for _, segment := range segments {
+		split += segment
+
+		wait := time.NewTimer(clock.unscale(start.Add(split).Sub(clock.Now())))
+		select {
+		case <-wait.C:
+		case <-stop:
+			wait.Stop()
+			return
+		}
+
+		clock.Hold(start.Add(split))
+		t.Split()
+		clock.Release()
+	}
+}
@@ -0,0 +1,140 @@
+package timer
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	s := time.Second
+	comparison := []time.Duration{2 * s, 2 * s, 2 * s}
+	golds := []time.Duration{1 * s, 2 * s, 1 * s}
+
+	tests := []struct {
+		name       string
+		speed      float64
+		startIndex int
+		segments   []time.Duration
+		wantEvents []Event
+	}{
+		{
+			name:     "full run",
+			speed:    100,
+			segments: []time.Duration{1500 * time.Millisecond, 1 * s, 2 * s},
+			wantEvents: []Event{
+				{Type: Split, Index: 0, Segment: 1500 * time.Millisecond, Split: 1500 * time.Millisecond},
+				{Type: Split, Index: 1, Segment: 1 * s, Split: 2500 * time.Millisecond, Gold: true},
+				{Type: Finish, Index: 2, Segment: 2 * s, Split: 4500 * time.Millisecond},
+			},
+		},
+		{
+			name:       "partial run",
+			speed:      1000,
+			startIndex: 1,
+			segments:   []time.Duration{3 * s, 500 * time.Millisecond},
+			wantEvents: []Event{
+				{Type: Split, Index: 1, Segment: 3 * s, Split: 3 * s},
+				{Type: Finish, Index: 2, Segment: 500 * time.Millisecond, Split: 3500 * time.Millisecond, Gold: true},
+			},
+		},
+		{
+			name:     "slow replay",
+			speed:    0.5,
+			segments: []time.Duration{20 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond},
+			wantEvents: []Event{
+				{Type: Split, Index: 0, Segment: 20 * time.Millisecond, Split: 20 * time.Millisecond, Gold: true},
+				{Type: Split, Index: 1, Segment: 10 * time.Millisecond, Split: 30 * time.Millisecond, Gold: true},
+				{Type: Finish, Index: 2, Segment: 30 * time.Millisecond, Split: 60 * time.Millisecond, Gold: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewReplayClock(tt.speed)
+			timer := New(testData(comparison, golds), clock)
+			timer.StartFrom(tt.startIndex, 0)
+
+			var (
+				mu     sync.Mutex
+				events []Event
+			)
+			timer.Subscribe(func(e Event) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				// Only the fields that don't depend on the system clock are compared.
+				events = append(events, Event{Type: e.Type, Index: e.Index, Segment: e.Segment, Split: e.Split, Gold: e.Gold})
+			})
+
+			began := time.Now()
+			Replay(timer, clock, tt.segments, make(chan struct{}))
+			took := time.Since(began)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("events = %+v, want %+v", events, tt.wantEvents)
+			}
+
+			wantSegments := make([]time.Duration, len(comparison))
+			copy(wantSegments[tt.startIndex:], tt.segments)
+			if got := timer.Segments(); !reflect.DeepEqual(got, wantSegments) {
+				t.Errorf("segments = %v, want %v", got, wantSegments)
+			}
+
+			// Each split is made at exactly the start plus the replayed run time.
+			var (
+				total      time.Duration
+				start      = timer.Start()
+				splitTimes = timer.SplitTimes()
+			)
+			for i, segment := range tt.segments {
+				total += segment
+				if want := start.Add(total); !splitTimes[tt.startIndex+i].Equal(want) {
+					t.Errorf("split %d at %s, want %s", tt.startIndex+i, splitTimes[tt.startIndex+i], want)
+				}
+			}
+			if !timer.IsDone() || timer.TotalDuration() != total {
+				t.Errorf("done = %t in %s, want done in %s", timer.IsDone(), timer.TotalDuration(), total)
+			}
+
+			// The replay takes the run time divided by the speed.
+			want := time.Duration(float64(total) / tt.speed)
+			if took < want-5*time.Millisecond || took > want+500*time.Millisecond {
+				t.Errorf("replay took %s at %gx, want about %s", took, tt.speed, want)
+			}
+		})
+	}
+}
+
+func TestReplayStop(t *testing.T) {
+	clock := NewReplayClock(100)
+	timer := New(testData([]time.Duration{time.Second, time.Second}, nil), clock)
+
+	// The second segment would take an hour to replay.
+	stop := make(chan struct{})
+	timer.Subscribe(func(e Event) {
+		if e.Type == Split {
+			close(stop)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		Replay(timer, clock, []time.Duration{time.Second, 100 * time.Hour}, stop)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("replay didn't return after stop was closed")
+	}
+
+	if got := timer.Segments(); got[0] != time.Second || got[1] != 0 || timer.IsDone() {
+		t.Errorf("segments = %v after stopping during the second segment", got)
+	}
+}
@@ -0,0 +1,441 @@
+// Package timer times runs of a route.
+// It has no user interface: frontends drive it with Split, Undo and Reset and subscribe to its events.
+package timer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+)
+
+// Start showing the difference from the comparison once the runner is within this of the comparison split.
+const plusMinusThreshold = -10 * time.Second
+
+// Timer is the state of a run in progress.
+// It is safe to use from multiple goroutines.
+type Timer struct {
+	// Guards the fields below it.
+	mu sync.Mutex
+
+	// Held while events are published so subscribers receive them in order.
+	publishMu sync.Mutex
+
+	routeData *route.Data
+	clock     Clock
+
+	sumOfGold *time.Duration
+
+	// The split that the run starts from and the run time that the splits before it count as.
+	// Both are zero for a run from the first split.
+	startIndex int
+	offset     time.Duration
+
+	splitIndex    int
+	runStart      time.Time
+	segmentStart  time.Time
+	segments      []time.Duration
+	splitTimes    []time.Time
+	totalDuration time.Duration
+	paused        time.Duration
+
+	subscribers []func(Event)
+}
+
+// New returns a timer that is started at clock.Now().
+func New(routeData *route.Data, clock Clock) *Timer {
+	t := &Timer{
+		routeData:  routeData,
+		clock:      clock,
+		segments:   make([]time.Duration, routeData.Length),
+		splitTimes: make([]time.Time, routeData.Length),
+	}
+	t.start()
+	return t
+}
+
+func (t *Timer) start() {
+	now := t.clock.Now()
+
+	for i := range t.segments {
+		t.segments[i] = 0
+		t.splitTimes[i] = time.Time{}
+	}
+	t.splitIndex = t.startIndex
+	t.totalDuration = 0
+	t.paused = 0
+	t.runStart = now.Add(-t.offset)
+	t.segmentStart = now
+
+	t.sumOfGold = nil
+	if t.routeData.SumOfGold != nil {
+		sob := *t.routeData.SumOfGold
+		t.sumOfGold = &sob
+	}
+}
+
+// Subscribe calls f with every event that happens after it's called.
+// f is called from the goroutine that changed the timer.
+// It may call the timer's getters but must not call Split, Undo, Reset or Restore.
+func (t *Timer) Subscribe(f func(Event)) {
+	t.publishMu.Lock()
+	defer t.publishMu.Unlock()
+
+	t.subscribers = append(t.subscribers, f)
+}
+
+// Unlocks mu and sends events to subscribers.
+// The publish lock is taken before mu is released so that events arrive in the order they happened.
+func (t *Timer) unlockAndPublish(events ...Event) {
+	t.publishMu.Lock()
+	t.mu.Unlock()
+	defer t.publishMu.Unlock()
+
+	for _, e := range events {
+		for _, f := range t.subscribers {
+			f(e)
+		}
+	}
+}
+
+// RouteData returns the route that is being run.
+func (t *Timer) RouteData() *route.Data {
+	return t.routeData
+}
+
+// Snapshot is the state of a timer at one moment.
+type Snapshot struct {
+	SplitIndex       int            // The index of the active split.
+	Done             bool           // Whether every segment is completed.
+	Elapsed          time.Duration  // The run time.
+	SegmentElapsed   time.Duration  // The time spent in the active segment.
+	LastSplit        time.Duration  // The run time at the previous split.
+	Shift            time.Duration  // Added to the run time to line it up with the comparison, for a run that started from a later split without its time.
+	PlusMinus        time.Duration  // The difference between the run time and the comparison split.
+	ShowPlusMinus    bool           // False while the runner is far enough ahead that PlusMinus isn't interesting yet.
+	BestPossibleTime time.Duration  // The fastest the run can finish without beating any golds, lined up with the comparison like PlusMinus.
+	PredictedTime    time.Duration  // When the run finishes at the comparison's pace, lined up with the comparison like PlusMinus. Zero without a comparison.
+	PredictedByGolds time.Duration  // When the run finishes at the pace of the sum of best. Zero when a remaining split has no gold.
+	SumOfGold        *time.Duration // The sum of gold including golds beaten in this run.
+}
+
+// Snapshot returns the state of the timer.
+// Frontends should draw from a snapshot so that every value is from the same moment.
+func (t *Timer) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Snapshot{
+		SplitIndex:     t.splitIndex,
+		Done:           t.isDone(),
+		Elapsed:        t.elapsed(),
+		SegmentElapsed: t.segmentElapsed(),
+		LastSplit:      t.lastSplit(),
+		Shift:          t.shift(),
+		SumOfGold:      t.copySumOfGold(),
+	}
+	s.PlusMinus, s.ShowPlusMinus = t.plusMinus(s.Elapsed)
+	s.BestPossibleTime = t.routeData.GetBPT(s.SplitIndex, s.LastSplit+s.Shift, s.PlusMinus)
+	s.PredictedTime = t.routeData.GetPredictedTime(s.SplitIndex, s.LastSplit+s.Shift, s.SegmentElapsed, false)
+	s.PredictedByGolds = t.routeData.GetPredictedTime(s.SplitIndex, s.LastSplit+s.Shift, s.SegmentElapsed, true)
+	return s
+}
+
+// SplitIndex returns the index of the active split.
+func (t *Timer) SplitIndex() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.splitIndex
+}
+
+// Segments returns a copy of the run's segments.
+// Segments that are not completed are zero.
+func (t *Timer) Segments() []time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	segments := make([]time.Duration, len(t.segments))
+	copy(segments, t.segments)
+	return segments
+}
+
+// SplitTimes returns a copy of when each split was made.
+// Splits that are not made are the zero time.
+func (t *Timer) SplitTimes() []time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	splitTimes := make([]time.Time, len(t.splitTimes))
+	copy(splitTimes, t.splitTimes)
+	return splitTimes
+}
+
+// IsDone returns whether every segment is completed.
+func (t *Timer) IsDone() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.isDone()
+}
+
+func (t *Timer) isDone() bool {
+	return t.segments[len(t.segments)-1] != 0
+}
+
+// TotalDuration returns the time of the run once it is done.
+func (t *Timer) TotalDuration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.totalDuration
+}
+
+// Start returns when the run was started.
+// For a run from a later split this is when that split was started.
+func (t *Timer) Start() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.runStart.Add(t.offset - t.paused)
+}
+
+// StartFrom starts the run over from the split at index.
+// offset is the run time that the splits before index count as, such as the comparison's time at the previous split.
+// The segments before index aren't part of the run.
+func (t *Timer) StartFrom(index int, offset time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.startIndex = index
+	t.offset = offset
+	t.start()
+}
+
+// StartIndex returns the split that the run started from.
+func (t *Timer) StartIndex() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.startIndex
+}
+
+// Offset returns the run time that the splits before the start index count as.
+func (t *Timer) Offset() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.offset
+}
+
+// Paused returns how long the run was stopped for.
+func (t *Timer) Paused() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.paused
+}
+
+// SumOfGold returns the sum of gold including golds beaten in this run.
+// Nil when the route has no completed runs.
+func (t *Timer) SumOfGold() *time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.copySumOfGold()
+}
+
+func (t *Timer) copySumOfGold() *time.Duration {
+	if t.sumOfGold == nil {
+		return nil
+	}
+	sob := *t.sumOfGold
+	return &sob
+}
+
+// Elapsed returns the run time.
+func (t *Timer) Elapsed() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.elapsed()
+}
+
+func (t *Timer) elapsed() time.Duration {
+	if t.isDone() {
+		return t.totalDuration
+	}
+	return t.clock.Now().Sub(t.runStart)
+}
+
+func (t *Timer) segmentElapsed() time.Duration {
+	if t.isDone() {
+		return t.segments[t.splitIndex]
+	}
+	return t.clock.Now().Sub(t.segmentStart)
+}
+
+func (t *Timer) lastSplit() time.Duration {
+	return t.segmentStart.Sub(t.runStart)
+}
+
+// Returns what lines the run time up with the comparison when the run started from a later split without its time.
+// It is zero for full runs and runs that start from the comparison's time.
+func (t *Timer) shift() time.Duration {
+	if t.startIndex == 0 {
+		return 0
+	}
+	return t.routeData.GetComparisonSplit(t.startIndex-1) - t.offset
+}
+
+// Returns the difference between total and the comparison at the active split.
+// show is false while the runner is far enough ahead that the difference isn't interesting yet.
+func (t *Timer) plusMinus(total time.Duration) (diff time.Duration, show bool) {
+	var lastDiff time.Duration
+
+	shift := t.shift()
+	diff = total + shift - t.routeData.GetComparisonSplit(t.splitIndex)
+
+	if t.splitIndex > 0 {
+		lastDiff = t.lastSplit() + shift - t.routeData.GetComparisonSplit(t.splitIndex-1)
+	}
+
+	if diff < 0 && lastDiff < 0 {
+		show = diff > plusMinusThreshold+lastDiff
+	} else if diff < 0 {
+		show = diff > plusMinusThreshold
+	} else {
+		show = true
+	}
+	return
+}
+
+// Split completes the active segment.
+// It does nothing once the run is done.
+func (t *Timer) Split() {
+	t.mu.Lock()
+	if t.isDone() {
+		t.mu.Unlock()
+		return
+	}
+
+	now := t.clock.Now()
+	e := t.completeSegment(now.Sub(t.segmentStart), now.Sub(t.runStart))
+	e.Time = now
+	t.splitTimes[t.splitIndex] = now
+
+	if t.splitIndex < t.routeData.Length-1 {
+		t.segmentStart = now
+		t.splitIndex++
+	} else {
+		e.Type = Finish
+		t.totalDuration = e.Split
+	}
+
+	t.unlockAndPublish(e)
+}
+
+func (t *Timer) completeSegment(segment, split time.Duration) Event {
+	diff, _ := t.plusMinus(split)
+
+	e := Event{
+		Type:      Split,
+		Index:     t.splitIndex,
+		Segment:   segment,
+		Split:     split,
+		PlusMinus: diff,
+	}
+
+	t.segments[t.splitIndex] = segment
+	if gold := t.routeData.GetGold(t.splitIndex); segment < gold {
+		e.Gold = true
+		if t.sumOfGold != nil {
+			*t.sumOfGold -= gold - segment
+		}
+	}
+	return e
+}
+
+// Undo takes back the last split.
+// The time since the split is added back onto the segment before it.
+func (t *Timer) Undo() {
+	t.mu.Lock()
+
+	if t.isDone() {
+		// Reopen the last segment.
+		t.undoGold(t.splitIndex)
+		t.segments[t.splitIndex] = 0
+		t.splitTimes[t.splitIndex] = time.Time{}
+		t.totalDuration = 0
+	} else if t.splitIndex > t.startIndex {
+		t.splitIndex--
+		t.undoGold(t.splitIndex)
+		lastSegment := t.segments[t.splitIndex]
+		t.segments[t.splitIndex] = 0
+		t.splitTimes[t.splitIndex] = time.Time{}
+		t.segmentStart = t.segmentStart.Add(-lastSegment)
+	} else {
+		t.mu.Unlock()
+		return
+	}
+
+	t.unlockAndPublish(Event{Type: Undo, Time: t.clock.Now(), Index: t.splitIndex})
+}
+
+// Adds a beaten gold at index back onto the sum of gold.
+func (t *Timer) undoGold(index int) {
+	if gold := t.routeData.GetGold(index); t.segments[index] < gold && t.sumOfGold != nil {
+		*t.sumOfGold += gold - t.segments[index]
+	}
+}
+
+// Reset starts the run over.
+// The event's index is the split that the run was reset at.
+func (t *Timer) Reset() {
+	t.mu.Lock()
+	reached := t.splitIndex
+	t.start()
+	t.unlockAndPublish(Event{Type: Reset, Time: t.runStart, Index: reached})
+}
+
+// Restore continues a run from its completed segments and when they were split.
+// paused is how long the run was stopped for; the run time doesn't include it.
+// The segment that was in progress is started over.
+// Subscribers receive an event for each restored segment.
+// A run from a later split is restored by calling StartFrom first.
+func (t *Timer) Restore(segments []time.Duration, splitTimes []time.Time, paused time.Duration) {
+	t.mu.Lock()
+	t.start()
+
+	events := []Event{}
+	now := t.clock.Now()
+	split := t.offset
+	for i := t.startIndex; i < len(segments); i++ {
+		segment := segments[i]
+		if segment == 0 || t.isDone() {
+			break
+		}
+		if i < len(splitTimes) {
+			t.splitTimes[t.splitIndex] = splitTimes[i]
+		}
+		split += segment
+		t.runStart = now.Add(-split)
+		t.segmentStart = t.runStart.Add(split - segment)
+
+		e := t.completeSegment(segment, split)
+		e.Time = now
+		if t.splitIndex < t.routeData.Length-1 {
+			t.splitIndex++
+		} else {
+			e.Type = Finish
+			t.totalDuration = split
+		}
+		events = append(events, e)
+	}
+
+	t.runStart = now.Add(-split)
+	t.segmentStart = now
+	t.paused = paused
+
+	t.unlockAndPublish(events...)
+}
@@ -0,0 +1,478 @@
+package timer
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knoebber/gsplits/route"
+	"github.com/knoebber/gsplits/split"
+)
+
+// fakeClock is a clock that only moves when it's advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Returns route data with a split for each comparison segment.
+// Golds are set when golds isn't nil.
+func testData(comparison, golds []time.Duration) *route.Data {
+	d := &route.Data{
+		RouteName: "test",
+		RouteID:   1,
+		Length:    len(comparison),
+	}
+
+	var total, sumOfGold time.Duration
+	for i, segment := range comparison {
+		total += segment
+		d.SplitNames = append(d.SplitNames, split.Name{ID: int64(i + 1), Position: i + 1})
+		d.ComparisonSegments = append(d.ComparisonSegments, segment)
+		d.ComparisonSplits = append(d.ComparisonSplits, total)
+	}
+	if golds != nil {
+		for i, gold := range golds {
+			sumOfGold += gold
+			d.Golds = append(d.Golds, gold)
+			d.TimeSaves = append(d.TimeSaves, comparison[i]-gold)
+		}
+		d.SumOfGold = &sumOfGold
+		d.RouteBestTime = &total
+	}
+	return d
+}
+
+// step advances the clock and then calls action on the timer.
+type step struct {
+	advance time.Duration
+	action  func(*Timer)
+}
+
+func TestTimer(t *testing.T) {
+	s := time.Second
+
+	tests := []struct {
+		name         string
+		steps        []step
+		wantIndex    int
+		wantDone     bool
+		wantSegments []time.Duration
+		wantTotal    time.Duration
+		wantElapsed  time.Duration
+		wantGold     time.Duration // The sum of gold, which golds in the run lower.
+		wantEvents   []EventType
+	}{
+		{
+			name:         "new",
+			wantSegments: []time.Duration{0, 0, 0},
+			wantGold:     6 * s,
+		},
+		{
+			name:         "split",
+			steps:        []step{{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}},
+			wantIndex:    2,
+			wantSegments: []time.Duration{2 * s, 3 * s, 0},
+			wantElapsed:  5 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split},
+		},
+		{
+			name:         "finish",
+			steps:        []step{{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}, {4 * s, (*Timer).Split}},
+			wantIndex:    2,
+			wantDone:     true,
+			wantSegments: []time.Duration{2 * s, 3 * s, 4 * s},
+			wantTotal:    9 * s,
+			wantElapsed:  9 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Finish},
+		},
+		{
+			name: "split after finish does nothing",
+			steps: []step{
+				{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}, {4 * s, (*Timer).Split},
+				{5 * s, (*Timer).Split},
+			},
+			wantIndex:    2,
+			wantDone:     true,
+			wantSegments: []time.Duration{2 * s, 3 * s, 4 * s},
+			wantTotal:    9 * s,
+			wantElapsed:  9 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Finish},
+		},
+		{
+			name:         "undo adds the time back onto the segment",
+			steps:        []step{{2 * s, (*Timer).Split}, {1 * s, (*Timer).Undo}, {1 * s, (*Timer).Split}},
+			wantIndex:    1,
+			wantSegments: []time.Duration{4 * s, 0, 0},
+			wantElapsed:  4 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Undo, Split},
+		},
+		{
+			name:         "undo before the first split does nothing",
+			steps:        []step{{2 * s, (*Timer).Undo}},
+			wantSegments: []time.Duration{0, 0, 0},
+			wantElapsed:  2 * s,
+			wantGold:     6 * s,
+		},
+		{
+			name: "undo after finish reopens the last segment",
+			steps: []step{
+				{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}, {4 * s, (*Timer).Split},
+				{1 * s, (*Timer).Undo},
+			},
+			wantIndex:    2,
+			wantSegments: []time.Duration{2 * s, 3 * s, 0},
+			wantElapsed:  10 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Finish, Undo},
+		},
+		{
+			name: "split after reopening the last segment finishes again",
+			steps: []step{
+				{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}, {4 * s, (*Timer).Split},
+				{1 * s, (*Timer).Undo}, {1 * s, (*Timer).Split},
+			},
+			wantIndex:    2,
+			wantDone:     true,
+			wantSegments: []time.Duration{2 * s, 3 * s, 6 * s},
+			wantTotal:    11 * s,
+			wantElapsed:  11 * s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Finish, Undo, Finish},
+		},
+		{
+			name:         "gold lowers the sum of gold",
+			steps:        []step{{500 * time.Millisecond, (*Timer).Split}},
+			wantIndex:    1,
+			wantSegments: []time.Duration{500 * time.Millisecond, 0, 0},
+			wantElapsed:  500 * time.Millisecond,
+			wantGold:     5500 * time.Millisecond,
+			wantEvents:   []EventType{Split},
+		},
+		{
+			name:         "undo gives the gold back",
+			steps:        []step{{500 * time.Millisecond, (*Timer).Split}, {0, (*Timer).Undo}},
+			wantSegments: []time.Duration{0, 0, 0},
+			wantElapsed:  500 * time.Millisecond,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Undo},
+		},
+		{
+			name:         "reset",
+			steps:        []step{{500 * time.Millisecond, (*Timer).Split}, {3 * s, (*Timer).Split}, {s, (*Timer).Reset}},
+			wantSegments: []time.Duration{0, 0, 0},
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Reset},
+		},
+		{
+			name: "reset after finish",
+			steps: []step{
+				{2 * s, (*Timer).Split}, {3 * s, (*Timer).Split}, {4 * s, (*Timer).Split},
+				{s, (*Timer).Reset}, {s, nil},
+			},
+			wantSegments: []time.Duration{0, 0, 0},
+			wantElapsed:  s,
+			wantGold:     6 * s,
+			wantEvents:   []EventType{Split, Split, Finish, Reset},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			tm := New(testData(
+				[]time.Duration{2 * time.Second, 3 * time.Second, 4 * time.Second},
+				[]time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second},
+			), clock)
+
+			var events []EventType
+			tm.Subscribe(func(e Event) { events = append(events, e.Type) })
+
+			for _, st := range test.steps {
+				clock.advance(st.advance)
+				if st.action != nil {
+					st.action(tm)
+				}
+			}
+
+			if got := tm.SplitIndex(); got != test.wantIndex {
+				t.Errorf("SplitIndex() = %d, want %d", got, test.wantIndex)
+			}
+			if got := tm.IsDone(); got != test.wantDone {
+				t.Errorf("IsDone() = %t, want %t", got, test.wantDone)
+			}
+			if got := tm.Segments(); !reflect.DeepEqual(got, test.wantSegments) {
+				t.Errorf("Segments() = %v, want %v", got, test.wantSegments)
+			}
+			if got := tm.TotalDuration(); got != test.wantTotal {
+				t.Errorf("TotalDuration() = %s, want %s", got, test.wantTotal)
+			}
+			if got := tm.Elapsed(); got != test.wantElapsed {
+				t.Errorf("Elapsed() = %s, want %s", got, test.wantElapsed)
+			}
+			if got := tm.SumOfGold(); got == nil || *got != test.wantGold {
+				t.Errorf("SumOfGold() = %v, want %s", got, test.wantGold)
+			}
+			if !reflect.DeepEqual(events, test.wantEvents) {
+				t.Errorf("events = %v, want %v", events, test.wantEvents)
+			}
+		})
+	}
+}
+
+func TestPlusMinus(t *testing.T) {
+	s := time.Second
+	clock := newFakeClock()
+	tm := New(testData([]time.Duration{20 * s, 20 * s}, nil), clock)
+
+	var last Event
+	tm.Subscribe(func(e Event) { last = e })
+
+	clock.advance(5 * s)
+	if snapshot := tm.Snapshot(); snapshot.ShowPlusMinus || snapshot.PlusMinus != -15*s {
+		t.Errorf("far ahead: PlusMinus = %s, ShowPlusMinus = %t", snapshot.PlusMinus, snapshot.ShowPlusMinus)
+	}
+
+	clock.advance(13 * s)
+	if snapshot := tm.Snapshot(); !snapshot.ShowPlusMinus || snapshot.PlusMinus != -2*s {
+		t.Errorf("close: PlusMinus = %s, ShowPlusMinus = %t", snapshot.PlusMinus, snapshot.ShowPlusMinus)
+	}
+
+	clock.advance(4 * s)
+	tm.Split()
+	if last.Type != Split || last.PlusMinus != 2*s || last.Split != 22*s {
+		t.Errorf("split event = %+v", last)
+	}
+}
+
+func TestPredictions(t *testing.T) {
+	s := time.Second
+	comparison := []time.Duration{2 * s, 3 * s, 4 * s}
+
+	tests := []struct {
+		name          string
+		golds         []time.Duration
+		startIndex    int
+		offset        time.Duration
+		splits        int
+		wantPredicted time.Duration
+		wantByGolds   time.Duration
+		wantBPT       time.Duration
+	}{
+		{name: "full run", golds: []time.Duration{1 * s, 2 * s, 3 * s}, wantPredicted: 9 * s, wantByGolds: 6 * s, wantBPT: 6 * s},
+		{name: "started with the comparison's time", golds: []time.Duration{1 * s, 2 * s, 3 * s}, startIndex: 1, offset: 2 * s, wantPredicted: 9 * s, wantByGolds: 7 * s, wantBPT: 7 * s},
+		{name: "started blank", golds: []time.Duration{1 * s, 2 * s, 3 * s}, startIndex: 1, wantPredicted: 9 * s, wantByGolds: 7 * s, wantBPT: 7 * s},
+		{name: "missing gold", golds: []time.Duration{1 * s, 0, 3 * s}, wantPredicted: 9 * s, wantBPT: 4 * s},
+		{name: "past the missing gold", golds: []time.Duration{1 * s, 0, 3 * s}, splits: 2, wantPredicted: 10 * s, wantByGolds: 9 * s, wantBPT: 9 * s},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			tm := New(testData(comparison, test.golds), clock)
+			tm.StartFrom(test.startIndex, test.offset)
+			for i := 0; i < test.splits; i++ {
+				clock.advance(3 * s)
+				tm.Split()
+			}
+			clock.advance(time.Second)
+
+			snapshot := tm.Snapshot()
+			if snapshot.PredictedTime != test.wantPredicted {
+				t.Errorf("PredictedTime = %s, want %s", snapshot.PredictedTime, test.wantPredicted)
+			}
+			if snapshot.PredictedByGolds != test.wantByGolds {
+				t.Errorf("PredictedByGolds = %s, want %s", snapshot.PredictedByGolds, test.wantByGolds)
+			}
+			if snapshot.BestPossibleTime != test.wantBPT {
+				t.Errorf("BestPossibleTime = %s, want %s", snapshot.BestPossibleTime, test.wantBPT)
+			}
+		})
+	}
+}
+
+func TestStartFrom(t *testing.T) {
+	s := time.Second
+	clock := newFakeClock()
+	tm := New(testData([]time.Duration{2 * s, 3 * s, 4 * s}, nil), clock)
+
+	tm.StartFrom(1, 2*s)
+	clock.advance(3 * s)
+	tm.Undo()
+	if got := tm.SplitIndex(); got != 1 {
+		t.Fatalf("Undo() moved before the start index to %d", got)
+	}
+
+	tm.Split()
+	clock.advance(5 * s)
+	tm.Split()
+
+	if !tm.IsDone() {
+		t.Fatal("IsDone() = false after splitting every segment from the start index")
+	}
+	if got, want := tm.Segments(), []time.Duration{0, 3 * s, 5 * s}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Segments() = %v, want %v", got, want)
+	}
+	if got := tm.TotalDuration(); got != 10*s {
+		t.Errorf("TotalDuration() = %s, want the offset and segments, 10s", got)
+	}
+
+	tm.Reset()
+	if got := tm.SplitIndex(); got != 1 {
+		t.Errorf("Reset() went back to %d instead of the start index", got)
+	}
+}
+
+func TestRestorePaused(t *testing.T) {
+	s := time.Second
+	clock := newFakeClock()
+	tm := New(testData([]time.Duration{2 * s, 3 * s, 4 * s}, []time.Duration{s, s, s}), clock)
+
+	var events []EventType
+	tm.Subscribe(func(e Event) { events = append(events, e.Type) })
+
+	tm.Restore([]time.Duration{2 * s, 3 * s, 0}, nil, 10*s)
+
+	if got := tm.SplitIndex(); got != 2 {
+		t.Errorf("SplitIndex() = %d, want 2", got)
+	}
+	if got := tm.Elapsed(); got != 5*s {
+		t.Errorf("Elapsed() = %s, want the restored segments without the pause, 5s", got)
+	}
+	if got := tm.Paused(); got != 10*s {
+		t.Errorf("Paused() = %s, want 10s", got)
+	}
+	if got, want := tm.Start(), clock.Now().Add(-15*s); !got.Equal(want) {
+		t.Errorf("Start() = %s, want %s", got, want)
+	}
+	if want := []EventType{Split, Split}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	clock.advance(4 * s)
+	tm.Split()
+	if !tm.IsDone() || tm.TotalDuration() != 9*s {
+		t.Errorf("after finishing: IsDone() = %t, TotalDuration() = %s", tm.IsDone(), tm.TotalDuration())
+	}
+}
+
+// Splits, undoes, resets and reads a timer from several goroutines.
+// Run with -race. Subscribers have to receive events in the order that they changed the timer,
+// so following the events has to end on the timer's split index.
+func TestConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		iterations = 500
+	)
+
+	clock := newFakeClock()
+	tm := New(testData(
+		[]time.Duration{time.Second, time.Second, time.Second, time.Second},
+		[]time.Duration{time.Second, time.Second, time.Second, time.Second},
+	), clock)
+
+	// Only changed by the subscriber, which is called while events are published one at a time.
+	var (
+		index      int
+		done       bool
+		outOfOrder int
+	)
+	tm.Subscribe(func(e Event) {
+		// Every event has to follow from the one before it.
+		switch e.Type {
+		case Split:
+			if e.Index != index || done {
+				outOfOrder++
+			}
+			index = e.Index + 1
+		case Finish:
+			if e.Index != index || done {
+				outOfOrder++
+			}
+			done = true
+		case Undo:
+			if done && e.Index != index || !done && e.Index != index-1 {
+				outOfOrder++
+			}
+			index = e.Index
+			done = false
+		case Reset:
+			if e.Index != index {
+				outOfOrder++
+			}
+			index = 0
+			done = false
+		}
+
+		// Subscribers may read the timer while it's being changed.
+		tm.Snapshot()
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				clock.advance(time.Millisecond)
+				switch (g + i) % 7 {
+				case 0, 1, 2:
+					tm.Split()
+				case 3, 4:
+					tm.Undo()
+				case 5:
+					tm.Reset()
+				default:
+					snapshot := tm.Snapshot()
+					if snapshot.SplitIndex < 0 || snapshot.SplitIndex >= 4 {
+						t.Errorf("snapshot split index %d is out of range", snapshot.SplitIndex)
+					}
+					tm.Segments()
+					tm.SumOfGold()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if outOfOrder > 0 {
+		t.Errorf("%d events didn't follow from the event before them", outOfOrder)
+	}
+	if got := tm.SplitIndex(); got != index {
+		t.Errorf("SplitIndex() = %d, but the events end on %d", got, index)
+	}
+
+	// Segments from the active split on aren't completed, unless the run is done.
+	segments := tm.Segments()
+	if !tm.IsDone() {
+		for i := tm.SplitIndex(); i < len(segments); i++ {
+			if segments[i] != 0 {
+				t.Errorf("segment %d = %s after the active split %d", i, segments[i], tm.SplitIndex())
+			}
+		}
+	}
+}
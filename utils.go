@@ -15,9 +15,6 @@ const (
 	// The minimum size a duration string will be.
 	// Prevents containers from resizing as the duration size changes sizes.
 	minDurationLength = 10
-
-	// Start showing time save within this many nano seconds
-	plusMinusThreshold = (10 * 1e9) * -1
 )
 
 func newText(text string) *tview.TextView {
@@ -49,10 +46,6 @@ func safeDurationStr(d *time.Duration) string {
 	return durationStr(*d)
 }
 
-func elapsedStr(start time.Time) string {
-	return durationStr(time.Since(start))
-}
-
 func durationStr(d time.Duration) string {
 	// Show only as many digits at the refresh interval.
 	d = d - (d % refreshInterval)